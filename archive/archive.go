@@ -46,6 +46,47 @@ func NopCloser(r Reader) ReadCloser {
 	return nopCloser{r}
 }
 
+type singleFileWriter struct {
+	io.WriteCloser
+	name        string
+	noMoreFiles bool
+}
+
+// NewSingleFileWriter turns a stream compressor, like gzip, into a Writer
+// accepting a single file.
+func NewSingleFileWriter(w io.WriteCloser) WriteCloser {
+	return &singleFileWriter{WriteCloser: w}
+}
+
+func (w *singleFileWriter) NextFile(name string, _ os.FileInfo) error {
+	if w.noMoreFiles {
+		return fmt.Errorf("archive: only accepts one file: already received %q and now %q", w.name, name)
+	}
+	w.noMoreFiles = true
+	w.name = name
+	return nil
+}
+
+type singleFileReader struct {
+	io.ReadCloser
+	name  string
+	isEOF bool
+}
+
+// NewSingleFileReader turns a stream decompressor, like gzip, into a Reader
+// with a single file with the specified name.
+func NewSingleFileReader(r io.ReadCloser, name string) ReadCloser {
+	return &singleFileReader{ReadCloser: r, name: name}
+}
+
+func (r *singleFileReader) NextFile() (name string, err error) {
+	if r.isEOF {
+		return "", io.EOF
+	}
+	r.isEOF = true
+	return r.name, nil
+}
+
 // Copy copies from src to dest until either EOF is reached on src or an error
 // occurs.
 //
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"testing"
 
 	"github.com/cihub/seelog/archive"
@@ -176,3 +177,37 @@ func mustClose(t *testing.T, tname string, c io.Closer) {
 		t.Fatalf("%s: close: %v", tname, err)
 	}
 }
+
+func TestSingleFile(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := archive.NewSingleFileWriter(nopWriteCloser{buf})
+	contents := []byte("This is a single log.")
+	if err := w.NextFile("file1", iotest.FileInfo(t, contents)); err != nil {
+		t.Fatal(err)
+	}
+	mustCopy(t, "single file", w, bytes.NewReader(contents))
+	if err := w.NextFile("file2", iotest.FileInfo(t, contents)); err == nil {
+		t.Error("expected an error for the second file")
+	}
+	mustClose(t, "single file", w)
+
+	r := archive.NewSingleFileReader(ioutil.NopCloser(buf), "file1")
+	name, err := r.NextFile()
+	if err != nil || name != "file1" {
+		t.Fatalf("got file %q and error %v, want file %q", name, err, "file1")
+	}
+	got := new(bytes.Buffer)
+	mustCopy(t, "single file", got, r)
+	if !bytes.Equal(got.Bytes(), contents) {
+		t.Errorf("got %q but want %q", got.Bytes(), contents)
+	}
+	if _, err := r.NextFile(); err != io.EOF {
+		t.Errorf("got %v but want EOF", err)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -62,3 +62,13 @@ func (w *Writer) NextFile(name string, _ os.FileInfo) error {
 func NewWriter(w io.Writer) *Writer {
 	return &Writer{Writer: *gzip.NewWriter(w)}
 }
+
+// NewWriterLevel is like NewWriter but specifies the compression level instead
+// of assuming DefaultCompression. See compress/gzip for the valid levels.
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	gw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{Writer: *gw}, nil
+}
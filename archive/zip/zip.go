@@ -2,7 +2,9 @@ package zip
 
 import (
 	"archive/zip"
+	"compress/flate"
 	"io"
+	"io/ioutil"
 	"os"
 )
 
@@ -69,6 +71,19 @@ func NewWriter(w io.Writer) *Writer {
 	return &Writer{Writer: *zip.NewWriter(w)}
 }
 
+// NewWriterLevel is like NewWriter but compresses files with the specified
+// deflate level. See compress/flate for the valid levels.
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	if _, err := flate.NewWriter(ioutil.Discard, level); err != nil {
+		return nil, err
+	}
+	zw := NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+	return zw, nil
+}
+
 // NextFile computes and writes a header and prepares to accept the file's
 // contents.
 func (w *Writer) NextFile(name string, fi os.FileInfo) error {
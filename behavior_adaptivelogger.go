@@ -124,6 +124,6 @@ func (asnAdaptiveLogger *asyncAdaptiveLogger) processQueue() {
 
 		interval := asnAdaptiveLogger.calcAdaptiveInterval(itemCount)
 
-		<-time.After(interval)
+		<-asnAdaptiveLogger.currentClock().After(interval)
 	}
 }
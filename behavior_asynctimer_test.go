@@ -81,3 +81,14 @@ func Test_Asynctimer(t *testing.T) {
 
 	Current.Close()
 }
+
+func TestAsynctimerZeroInterval(t *testing.T) {
+	logger, err := LoggerFromConfigAsString(`<seelog type="asynctimer" asyncinterval="0"/>`)
+	if err == nil {
+		logger.Close()
+		t.Fatal("expected an error for a zero interval")
+	}
+	if logger != nil {
+		t.Errorf("expected no logger with the error, got %T", logger)
+	}
+}
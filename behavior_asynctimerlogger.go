@@ -77,6 +77,6 @@ func (asnTimerLogger *asyncTimerLogger) processQueue() {
 			break
 		}
 
-		<-time.After(asnTimerLogger.interval)
+		<-asnTimerLogger.currentClock().After(asnTimerLogger.interval)
 	}
 }
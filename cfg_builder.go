@@ -0,0 +1,506 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigBuilder builds a logger in code, element by element, in the same way as an XML config
+// describes it. The built config is validated by the same parser as XML configs, so a builder
+// and an XML config with the same elements and attributes create identical loggers.
+//
+// Example:
+//
+//	logger, err := seelog.NewConfigBuilder().
+//		Sync().
+//		MinLevel(seelog.InfoLvl).
+//		Format("main", "%Date %Time [%LEV] %Msg%n").
+//		Outputs().FormatID("main").
+//		Console().End().
+//		Filter(seelog.ErrorLvl, seelog.CriticalLvl).
+//		RollingFile("size", "errors.log").MaxSize(1000000).MaxRolls(5).Archive("gzip", "").End().
+//		End().
+//		Config().Build()
+type ConfigBuilder struct {
+	root    *xmlNode
+	formats *xmlNode
+	except  *xmlNode
+	outputs *OutputBuilder
+	params  *CfgParseParams
+	typeErr error // Invalid parameters of the logger type, returned by Build
+}
+
+// maxLoggerInterval is the longest async logger interval, as configs keep intervals
+// in 32-bit nanosecond counts.
+const maxLoggerInterval = time.Duration(math.MaxUint32)
+
+// NewConfigBuilder creates an empty config, which produces the same logger as an empty
+// '<seelog/>' XML config.
+func NewConfigBuilder() *ConfigBuilder {
+	root := newNode()
+	root.name = seelogConfigID
+	return &ConfigBuilder{root: root}
+}
+
+// Sync makes the logger synchronous.
+func (builder *ConfigBuilder) Sync() *ConfigBuilder {
+	return builder.loggerType(syncloggerTypeFromStringStr)
+}
+
+// AsyncLoop makes the logger process messages in a separate goroutine as soon as they come.
+func (builder *ConfigBuilder) AsyncLoop() *ConfigBuilder {
+	return builder.loggerType(asyncloggerTypeFromStringStr)
+}
+
+// AsyncTimer makes the logger process messages in a separate goroutine, one per interval.
+// The interval may not be longer than about 4.29s (2^32-1 nanoseconds).
+func (builder *ConfigBuilder) AsyncTimer(interval time.Duration) *ConfigBuilder {
+	builder.loggerType(asyncTimerloggerTypeFromStringStr)
+	builder.setInterval(asyncLoggerIntervalAttr, interval)
+	return builder
+}
+
+// Adaptive makes the logger process messages in a separate goroutine with an interval
+// between minInterval and maxInterval, depending on the queue length relative to criticalMsgCount.
+// The intervals may not be longer than about 4.29s (2^32-1 nanoseconds).
+func (builder *ConfigBuilder) Adaptive(minInterval, maxInterval time.Duration, criticalMsgCount uint32) *ConfigBuilder {
+	builder.loggerType(adaptiveLoggerTypeFromStringStr)
+	builder.setInterval(adaptLoggerMinIntervalAttr, minInterval)
+	builder.setInterval(adaptLoggerMaxIntervalAttr, maxInterval)
+	builder.root.attributes[adaptLoggerCriticalMsgCountAttr] = strconv.FormatUint(uint64(criticalMsgCount), 10)
+	return builder
+}
+
+func (builder *ConfigBuilder) loggerType(loggerType string) *ConfigBuilder {
+	for _, attr := range []string{asyncLoggerIntervalAttr, adaptLoggerMinIntervalAttr,
+		adaptLoggerMaxIntervalAttr, adaptLoggerCriticalMsgCountAttr} {
+		delete(builder.root.attributes, attr)
+	}
+	builder.root.attributes[loggerTypeFromStringAttr] = loggerType
+	builder.typeErr = nil
+	return builder
+}
+
+// setInterval sets an interval attribute of the logger type, keeping the error for Build
+// if the interval cannot be put in a config.
+func (builder *ConfigBuilder) setInterval(attr string, interval time.Duration) {
+	if interval < 0 || interval > maxLoggerInterval {
+		if builder.typeErr == nil {
+			builder.typeErr = fmt.Errorf("logger %s %v is out of range [0, %v]", attr, interval, maxLoggerInterval)
+		}
+		return
+	}
+	builder.root.attributes[attr] = strconv.FormatInt(int64(interval), 10)
+}
+
+// MinLevel sets the minimal level of logged messages.
+func (builder *ConfigBuilder) MinLevel(level LogLevel) *ConfigBuilder {
+	builder.root.attributes[minLevelID] = level.String()
+	return builder
+}
+
+// MaxLevel sets the maximal level of logged messages.
+func (builder *ConfigBuilder) MaxLevel(level LogLevel) *ConfigBuilder {
+	builder.root.attributes[maxLevelID] = level.String()
+	return builder
+}
+
+// Levels sets the list of logged levels, instead of MinLevel and MaxLevel.
+func (builder *ConfigBuilder) Levels(levels ...LogLevel) *ConfigBuilder {
+	builder.root.attributes[levelsID] = levelsString(levels)
+	return builder
+}
+
+// Format adds a format, which may be referenced by id in FormatID of outputs.
+func (builder *ConfigBuilder) Format(id, format string) *ConfigBuilder {
+	if builder.formats == nil {
+		builder.formats = builder.addSection(formatsID)
+	}
+	node := newNode()
+	node.name = formatID
+	node.attributes[formatKeyAttrID] = id
+	node.attributes[formatAttrID] = format
+	builder.formats.add(node)
+	return builder
+}
+
+// Exception adds an exception with its own minimal and maximal levels for the funcs and files
+// matching the patterns. An empty pattern matches everything.
+func (builder *ConfigBuilder) Exception(funcPattern, filePattern string, minLevel, maxLevel LogLevel) *ConfigBuilder {
+	node := builder.addException(funcPattern, filePattern)
+	node.attributes[minLevelID] = minLevel.String()
+	node.attributes[maxLevelID] = maxLevel.String()
+	return builder
+}
+
+// ExceptionLevels adds an exception with its own list of levels for the funcs and files
+// matching the patterns. An empty pattern matches everything.
+func (builder *ConfigBuilder) ExceptionLevels(funcPattern, filePattern string, levels ...LogLevel) *ConfigBuilder {
+	node := builder.addException(funcPattern, filePattern)
+	node.attributes[levelsID] = levelsString(levels)
+	return builder
+}
+
+func (builder *ConfigBuilder) addException(funcPattern, filePattern string) *xmlNode {
+	if builder.except == nil {
+		builder.except = builder.addSection(exceptionsID)
+	}
+	node := newNode()
+	node.name = exceptionID
+	if len(funcPattern) != 0 {
+		node.attributes[funcPatternID] = funcPattern
+	}
+	if len(filePattern) != 0 {
+		node.attributes[filePatternID] = filePattern
+	}
+	builder.except.add(node)
+	return node
+}
+
+func (builder *ConfigBuilder) addSection(name string) *xmlNode {
+	node := newNode()
+	node.name = name
+	builder.root.add(node)
+	return node
+}
+
+// Params sets the parse params, e.g. custom receiver producers or a clock.
+func (builder *ConfigBuilder) Params(params *CfgParseParams) *ConfigBuilder {
+	builder.params = params
+	return builder
+}
+
+// Outputs returns the root of the outputs tree. Without outputs, the logger writes to the console.
+func (builder *ConfigBuilder) Outputs() *OutputBuilder {
+	if builder.outputs == nil {
+		builder.outputs = &OutputBuilder{node: builder.addSection(outputsID), config: builder}
+	}
+	return builder.outputs
+}
+
+// Build validates the config and creates a logger.
+func (builder *ConfigBuilder) Build() (LoggerInterface, error) {
+	conf, err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	return createLoggerFromFullConfig(conf)
+}
+
+func (builder *ConfigBuilder) build() (*configForParsing, error) {
+	if builder.typeErr != nil {
+		return nil, builder.typeErr
+	}
+	// Parsers may keep or change nodes, so the builder stays reusable with a copy.
+	return configFromXMLNodeWithConfig(copyNode(builder.root), builder.params)
+}
+
+// String returns the config in the XML form.
+func (builder *ConfigBuilder) String() string {
+	return builder.root.String()
+}
+
+// OutputBuilder is an element of the outputs tree of a ConfigBuilder. Methods adding
+// child elements return the added child; End returns back to the parent element.
+type OutputBuilder struct {
+	node   *xmlNode
+	parent *OutputBuilder
+	config *ConfigBuilder
+}
+
+// Attr sets an attribute of the element, as in an XML config.
+func (output *OutputBuilder) Attr(name, value string) *OutputBuilder {
+	output.node.attributes[name] = value
+	return output
+}
+
+// FormatID sets the id of the format of the element.
+func (output *OutputBuilder) FormatID(id string) *OutputBuilder {
+	return output.Attr(outputFormatID, id)
+}
+
+// End returns the parent element. The root outputs element returns itself.
+func (output *OutputBuilder) End() *OutputBuilder {
+	if output.parent == nil {
+		return output
+	}
+	return output.parent
+}
+
+// Config returns the config the element belongs to.
+func (output *OutputBuilder) Config() *ConfigBuilder {
+	return output.config
+}
+
+// Element adds a child element with the given name, e.g. an element registered by a package
+// extending seelog, or a nested element of a writer such as an SMTP 'header'.
+func (output *OutputBuilder) Element(name string) *OutputBuilder {
+	node := newNode()
+	node.name = name
+	output.node.add(node)
+	return &OutputBuilder{node: node, parent: output, config: output.config}
+}
+
+// Splitter adds a dispatcher which sends messages to all of its children.
+func (output *OutputBuilder) Splitter() *OutputBuilder {
+	return output.Element(splitterDispatcherID)
+}
+
+// Filter adds a dispatcher which sends messages of the levels to its children. Without
+// levels, the filter needs rules, added with Contains, Regexp, FuncPattern, FilePattern
+// and ContextField, and messages of any level match them.
+func (output *OutputBuilder) Filter(levels ...LogLevel) *OutputBuilder {
+	filter := output.Element(filterDispatcherID)
+	if len(levels) != 0 {
+		filter.Attr(filterLevelsAttrID, levelsString(levels))
+	}
+	return filter
+}
+
+// Mode sets whether a filter passes the messages matching it or the other ones.
+func (output *OutputBuilder) Mode(mode FilterMode) *OutputBuilder {
+	return output.Attr(filterModeAttrID, mode.String())
+}
+
+// Contains adds a filter rule matching messages which contain the substring.
+func (output *OutputBuilder) Contains(substr string) *OutputBuilder {
+	return output.Attr(filterContainsAttrID, substr)
+}
+
+// Regexp adds a filter rule matching messages which match the regular expression.
+func (output *OutputBuilder) Regexp(expr string) *OutputBuilder {
+	return output.Attr(filterRegexpAttrID, expr)
+}
+
+// FuncPattern adds a filter rule matching messages logged from the funcs matching the pattern.
+func (output *OutputBuilder) FuncPattern(pattern string) *OutputBuilder {
+	return output.Attr(filterFuncPatternAttrID, pattern)
+}
+
+// FilePattern adds a filter rule matching messages logged from the files matching the pattern.
+func (output *OutputBuilder) FilePattern(pattern string) *OutputBuilder {
+	return output.Attr(filterFilePatternAttrID, pattern)
+}
+
+// ContextField adds a filter rule matching messages with the context field set to the value.
+func (output *OutputBuilder) ContextField(name, value string) *OutputBuilder {
+	return output.Attr(filterContextAttrID, name+"="+value)
+}
+
+// Redact adds a dispatcher which removes secrets matching the presets from messages.
+// More rules are added with RedactRule.
+func (output *OutputBuilder) Redact(presets ...string) *OutputBuilder {
+	redact := output.Element(redactDispatcherID)
+	if len(presets) != 0 {
+		redact.Attr(redactPresetsAttr, strings.Join(presets, ","))
+	}
+	return redact
+}
+
+// RedactRule adds a rule with a custom pattern to a redact dispatcher and returns the dispatcher.
+func (output *OutputBuilder) RedactRule(pattern string) *OutputBuilder {
+	output.Element(redactRuleID).Attr(redactRulePatternAttr, pattern)
+	return output
+}
+
+// Buffered adds a writer which buffers the data of its child writer. A zero flushPeriod
+// turns the periodic flushing off.
+func (output *OutputBuilder) Buffered(size int, flushPeriod time.Duration) *OutputBuilder {
+	buffered := output.Element(bufferedWriterID).Attr(bufferedSizeAttr, strconv.Itoa(size))
+	if flushPeriod != 0 {
+		buffered.Attr(bufferedFlushPeriodAttr, strconv.FormatInt(int64(flushPeriod/time.Millisecond), 10))
+	}
+	return buffered
+}
+
+// Console adds a writer to the standard output.
+func (output *OutputBuilder) Console() *OutputBuilder {
+	return output.Element(consoleWriterID)
+}
+
+// File adds a file writer.
+func (output *OutputBuilder) File(path string) *OutputBuilder {
+	return output.Element(fileWriterID).Attr(pathID, path)
+}
+
+// Lock makes a file or rolling file writer share the file with other processes, taking
+// an advisory lock for every write.
+func (output *OutputBuilder) Lock() *OutputBuilder {
+	return output.Attr(fileLockAttr, strconv.FormatBool(true))
+}
+
+// Sync sets when a file or rolling file writer syncs written data to the storage.
+func (output *OutputBuilder) Sync(policy SyncPolicy) *OutputBuilder {
+	for _, attr := range []string{fileSyncAttr, fileSyncLevelAttr, fileSyncIntervalAttr} {
+		delete(output.node.attributes, attr)
+	}
+	marshalSyncPolicy(output.node, policy)
+	return output
+}
+
+// ReopenInterval makes a file or rolling file writer reopen its file periodically, e.g.
+// to follow external log rotation.
+func (output *OutputBuilder) ReopenInterval(interval time.Duration) *OutputBuilder {
+	return output.Attr(reopenIntervalAttr, interval.String())
+}
+
+// Dynamic makes a file or rolling file writer produce its path from the context of every
+// message, keeping at most maxOpen files open and closing the files unused for idleTimeout.
+// Zero values keep the defaults.
+func (output *OutputBuilder) Dynamic(maxOpen int, idleTimeout time.Duration) *OutputBuilder {
+	output.Attr(dynamicFileAttr, strconv.FormatBool(true))
+	if maxOpen != 0 {
+		output.Attr(dynamicFileMaxOpenAttr, strconv.Itoa(maxOpen))
+	}
+	if idleTimeout != 0 {
+		output.Attr(dynamicFileIdleTimeoutAttr, idleTimeout.String())
+	}
+	return output
+}
+
+// RollingFile adds a rolling file writer of the type: "size", "date" or "datesize".
+// Options of the writer are set with the typed methods, such as MaxSize or DatePattern.
+func (output *OutputBuilder) RollingFile(rollingType, path string) *OutputBuilder {
+	return output.Element(rollingfileWriterID).Attr(rollingFileTypeAttr, rollingType).Attr(rollingFilePathAttr, path)
+}
+
+// MaxSize sets the size in bytes at which a "size" or "datesize" rolling file is rolled.
+func (output *OutputBuilder) MaxSize(size int64) *OutputBuilder {
+	return output.Attr(rollingFileMaxSizeAttr, strconv.FormatInt(size, 10))
+}
+
+// MaxRolls sets the number of rolled files which are kept. Zero keeps all of them.
+func (output *OutputBuilder) MaxRolls(rolls int) *OutputBuilder {
+	return output.Attr(rollingFileMaxRollsAttr, strconv.Itoa(rolls))
+}
+
+// DatePattern sets the date pattern of a "date" or "datesize" rolling file, e.g. "2006-01-02".
+func (output *OutputBuilder) DatePattern(pattern string) *OutputBuilder {
+	return output.Attr(rollingFileDataPatternAttr, pattern)
+}
+
+// NameMode sets whether the roll names are put after ("postfix") or before ("prefix")
+// the file name.
+func (output *OutputBuilder) NameMode(mode string) *OutputBuilder {
+	return output.Attr(rollingFileNameModeAttr, mode)
+}
+
+// Archive makes a rolling file writer put the removed rolls in an archive of the type:
+// "none", "zip" or "gzip". An empty path keeps the default archive name.
+func (output *OutputBuilder) Archive(archiveType, path string) *OutputBuilder {
+	output.Attr(rollingFileArchiveAttr, archiveType)
+	if len(path) != 0 {
+		output.Attr(rollingFileArchivePathAttr, path)
+	}
+	return output
+}
+
+// Retention makes a rolling file writer remove the rolls older than maxAge and the oldest
+// rolls above maxTotalSize bytes. Zero values turn the limits off.
+func (output *OutputBuilder) Retention(maxAge time.Duration, maxTotalSize int64) *OutputBuilder {
+	if maxAge != 0 {
+		output.Attr(rollingFileMaxAgeAttr, maxAge.String())
+	}
+	if maxTotalSize != 0 {
+		output.Attr(rollingFileMaxTotalSizeAttr, strconv.FormatInt(maxTotalSize, 10))
+	}
+	return output
+}
+
+// Symlink makes a rolling file writer keep a symlink with the name pointing to the current file.
+func (output *OutputBuilder) Symlink(name string) *OutputBuilder {
+	return output.Attr(rollingFileSymlinkAttr, name)
+}
+
+// Conn adds a network writer.
+func (output *OutputBuilder) Conn(network, addr string) *OutputBuilder {
+	return output.Element(connWriterID).Attr(connWriterNetAttr, network).Attr(connWriterAddrAttr, addr)
+}
+
+// SMTP adds a writer sending messages by email to the recipients.
+func (output *OutputBuilder) SMTP(senderAddress, senderName, host, port, user, password string, recipients ...string) *OutputBuilder {
+	smtp := output.Element(smtpWriterID).
+		Attr(senderaddressID, senderAddress).
+		Attr(senderNameID, senderName).
+		Attr(hostNameID, host).
+		Attr(hostPortID, port).
+		Attr(userNameID, user).
+		Attr(userPassID, password)
+	for _, recipient := range recipients {
+		smtp.Element(recipientID).Attr(addressID, recipient)
+	}
+	return smtp
+}
+
+// Memory adds a writer which keeps the last capacity messages in memory.
+func (output *OutputBuilder) Memory(name string, capacity int) *OutputBuilder {
+	return output.Element(memoryWriterID).Attr(memoryNameAttr, name).Attr(memoryCapacityAttr, strconv.Itoa(capacity))
+}
+
+// Custom adds a custom receiver registered with RegisterReceiver or provided by the
+// CustomReceiverProducers of the config params. Data is passed to the receiver as 'data-' attributes.
+func (output *OutputBuilder) Custom(name string, data map[string]string) *OutputBuilder {
+	custom := output.Element(customReceiverID).Attr(customNameAttrID, name)
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		custom.Attr(customNameDataAttrPrefix+key, data[key])
+	}
+	return custom
+}
+
+func levelsString(levels []LogLevel) string {
+	strs := make([]string, len(levels))
+	for i, level := range levels {
+		strs[i] = level.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+// copyNode returns a deep copy of the node.
+func copyNode(node *xmlNode) *xmlNode {
+	nodeCopy := &xmlNode{
+		name:         node.name,
+		attributes:   make(map[string]string, len(node.attributes)),
+		children:     make([]*xmlNode, 0, len(node.children)),
+		value:        node.value,
+		nodePosition: node.nodePosition,
+	}
+	for attr, value := range node.attributes {
+		nodeCopy.attributes[attr] = value
+	}
+	for _, child := range node.children {
+		nodeCopy.children = append(nodeCopy.children, copyNode(child))
+	}
+	return nodeCopy
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigBuilderMatchesXML(t *testing.T) {
+	defer cleanupAfterCfgTest(t)
+
+	builder := NewConfigBuilder().
+		Adaptive(10*time.Millisecond, 100*time.Millisecond, 50).
+		Levels(InfoLvl, ErrorLvl).
+		Format("main", "%Level %Msg").
+		Format("short", "%Lev %Msg").
+		Exception("main.*", "", TraceLvl, CriticalLvl).
+		ExceptionLevels("", "*.go", WarnLvl).
+		Outputs().FormatID("main").
+		Console().End().
+		Filter(ErrorLvl, CriticalLvl).FormatID("short").
+		File("builder_errors.log").End().
+		Buffered(1000, 10*time.Millisecond).
+		RollingFile("size", "builder_rolling.log").MaxSize(100).MaxRolls(3).End().
+		End().
+		End().
+		Splitter().
+		Redact("password").RedactRule(`key=\w+`).
+		Console().End().
+		End().
+		End().
+		Config()
+
+	xmlConfig := `
+<seelog type="adaptive" mininterval="10000000" maxinterval="100000000" critmsgcount="50" levels="info,error">
+	<formats>
+		<format id="main" format="%Level %Msg"/>
+		<format id="short" format="%Lev %Msg"/>
+	</formats>
+	<exceptions>
+		<exception funcpattern="main.*" minlevel="trace" maxlevel="critical"/>
+		<exception filepattern="*.go" levels="warn"/>
+	</exceptions>
+	<outputs formatid="main">
+		<console/>
+		<filter levels="error,critical" formatid="short">
+			<file path="builder_errors.log"/>
+			<buffered size="1000" flushperiod="10">
+				<rollingfile type="size" filename="builder_rolling.log" maxsize="100" maxrolls="3"/>
+			</buffered>
+		</filter>
+		<splitter>
+			<redact presets="password">
+				<rule pattern="key=\w+"/>
+				<console/>
+			</redact>
+		</splitter>
+	</outputs>
+</seelog>`
+
+	built, err := builder.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer built.RootDispatcher.Close()
+	parsed, err := configFromReader(strings.NewReader(xmlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parsed.RootDispatcher.Close()
+
+	if !configsAreEqual(built, parsed) {
+		t.Errorf("built config differs from the XML one:\n* Built: %v\n* Parsed: %v", built, parsed)
+	}
+}
+
+func TestConfigBuilderTypedOptions(t *testing.T) {
+	builder := NewConfigBuilder().
+		Outputs().
+		Filter().Mode(FilterExclude).Contains("ping").Regexp("^GET ").FuncPattern("main.*").
+		FilePattern("*_test.go").ContextField("user", "admin").
+		File("filtered.log").Lock().Sync(SyncPolicy{Mode: SyncLevel, Level: ErrorLvl}).End().
+		End().
+		RollingFile("datesize", "rolling.log").DatePattern("2006-01-02").MaxSize(1000).MaxRolls(5).
+		NameMode("prefix").Archive("gzip", "old").Retention(48*time.Hour, 1000000).Symlink("current.log").
+		ReopenInterval(time.Minute).Sync(SyncPolicy{Mode: SyncInterval, Interval: time.Second}).End().
+		File("%Ctx(user).log").Dynamic(10, time.Minute).End().
+		Config()
+
+	xmlConfig := `<seelog>
+	<outputs>
+		<filter mode="exclude" contains="ping" regexp="^GET " funcpattern="main.*" filepattern="*_test.go" context="user=admin">
+			<file path="filtered.log" lock="true" sync="level" synclevel="error"/>
+		</filter>
+		<rollingfile type="datesize" filename="rolling.log" datepattern="2006-01-02" maxsize="1000" maxrolls="5"
+			namemode="prefix" archivetype="gzip" archivepath="old" maxage="48h0m0s" maxtotalsize="1000000"
+			symlink="current.log" reopeninterval="1m0s" sync="interval" syncinterval="1s"/>
+		<file path="%Ctx(user).log" dynamic="true" maxopen="10" idletimeout="1m0s"/>
+	</outputs>
+</seelog>`
+	parsed, err := unmarshalConfig(strings.NewReader(xmlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	built := builder.root.marshalIndent("\t")
+	if expected := parsed.marshalIndent("\t"); string(built) != string(expected) {
+		t.Errorf("built config differs from the XML one:\n* Built: %s\n* Parsed: %s", built, expected)
+	}
+	if err := ValidateConfigAsBytes(built, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigBuilderEmpty(t *testing.T) {
+	built, err := NewConfigBuilder().build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := configFromReader(strings.NewReader("<seelog/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !configsAreEqual(built, parsed) {
+		t.Errorf("built config differs from the XML one:\n* Built: %v\n* Parsed: %v", built, parsed)
+	}
+}
+
+func TestConfigBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ConfigBuilder
+	}{
+		{"unknown format", NewConfigBuilder().Outputs().FormatID("missing").Console().End().Config()},
+		{"unknown attribute", NewConfigBuilder().Outputs().Console().Attr("unknown", "1").End().Config()},
+		{"filter without levels", NewConfigBuilder().Outputs().Filter().Console().End().End().Config()},
+		{"unknown custom receiver", NewConfigBuilder().Outputs().Custom("builder-missing", nil).End().Config()},
+		{"zero timer interval", NewConfigBuilder().AsyncTimer(0)},
+	}
+	for _, test := range tests {
+		if logger, err := test.builder.Build(); err == nil {
+			logger.Close()
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestConfigBuilderIntervals(t *testing.T) {
+	logger, err := NewConfigBuilder().AsyncTimer(time.Second).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Close()
+	logger, err = NewConfigBuilder().Adaptive(time.Second, 4*time.Second, 10).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Close()
+
+	tests := []struct {
+		name    string
+		builder *ConfigBuilder
+	}{
+		{"long timer interval", NewConfigBuilder().AsyncTimer(5 * time.Second)},
+		{"negative timer interval", NewConfigBuilder().AsyncTimer(-time.Second)},
+		{"long adaptive interval", NewConfigBuilder().Adaptive(time.Second, time.Minute, 10)},
+	}
+	for _, test := range tests {
+		_, err := test.builder.Build()
+		if err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("%s: expected an out of range error, got %v", test.name, err)
+		}
+	}
+}
+
+func TestConfigBuilderLoggerType(t *testing.T) {
+	builder := NewConfigBuilder().AsyncTimer(time.Millisecond).AsyncLoop()
+	if strings.Contains(builder.String(), asyncLoggerIntervalAttr) {
+		t.Errorf("changing the logger type must drop the parameters of the previous one: %s", builder)
+	}
+	logger, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	if _, ok := logger.(*asyncLoopLogger); !ok {
+		t.Errorf("expected an async loop logger, got %T", logger)
+	}
+}
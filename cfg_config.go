@@ -29,18 +29,12 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
-	"os"
 )
 
-// LoggerFromConfigAsFile creates logger with config from file. File should contain valid seelog xml.
+// LoggerFromConfigAsFile creates logger with config from file. File should contain valid seelog xml
+// or json config. Files with the '.json' extension are always parsed as json.
 func LoggerFromConfigAsFile(fileName string) (LoggerInterface, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	conf, err := configFromReader(file)
+	conf, err := configFromFile(fileName, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +42,8 @@ func LoggerFromConfigAsFile(fileName string) (LoggerInterface, error) {
 	return createLoggerFromFullConfig(conf)
 }
 
-// LoggerFromConfigAsBytes creates a logger with config from bytes stream. Bytes should contain valid seelog xml.
+// LoggerFromConfigAsBytes creates a logger with config from bytes stream. Bytes should contain valid seelog xml
+// or json config, the format is detected by the content.
 func LoggerFromConfigAsBytes(data []byte) (LoggerInterface, error) {
 	conf, err := configFromReader(bytes.NewBuffer(data))
 	if err != nil {
@@ -58,7 +53,8 @@ func LoggerFromConfigAsBytes(data []byte) (LoggerInterface, error) {
 	return createLoggerFromFullConfig(conf)
 }
 
-// LoggerFromConfigAsString creates a logger with config from a string. String should contain valid seelog xml.
+// LoggerFromConfigAsString creates a logger with config from a string. String should contain valid seelog xml
+// or json config, the format is detected by the content.
 func LoggerFromConfigAsString(data string) (LoggerInterface, error) {
 	return LoggerFromConfigAsBytes([]byte(data))
 }
@@ -66,13 +62,7 @@ func LoggerFromConfigAsString(data string) (LoggerInterface, error) {
 // LoggerFromParamConfigAsFile does the same as LoggerFromConfigAsFile, but includes special parser options.
 // See 'CfgParseParams' comments.
 func LoggerFromParamConfigAsFile(fileName string, parserParams *CfgParseParams) (LoggerInterface, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	conf, err := configFromReaderWithConfig(file, parserParams)
+	conf, err := configFromFile(fileName, parserParams)
 	if err != nil {
 		return nil, err
 	}
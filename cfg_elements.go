@@ -0,0 +1,333 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ElementWriterConstructor creates the writer of a config element registered with
+// RegisterWriterElement. Messages are formatted with the format of the element before
+// they reach the writer. If the writer implements Flush() or io.Closer, they are called
+// when the logger is flushed or closed.
+type ElementWriterConstructor func(element *ConfigElement) (io.Writer, error)
+
+// ElementDispatcherConstructor creates the dispatcher of a config element registered with
+// RegisterDispatcherElement.
+type ElementDispatcherConstructor func(element *ConfigElement) (ElementDispatcher, error)
+
+// dispatcherElements holds the names of the registered dispatcher elements.
+var dispatcherElements = make(map[string]bool)
+
+// RegisterWriterElement makes name a config element of the outputs section, like 'file' or
+// 'console'. When the parser meets the element, it calls the constructor, which checks the
+// attributes and child elements of the element and creates its writer:
+//
+//	seelog.RegisterWriterElement("syslog", func(element *seelog.ConfigElement) (io.Writer, error) {
+//	    if err := element.CheckAttrs("tag", "facility"); err != nil {
+//	        return nil, err
+//	    }
+//	    tag, err := element.RequiredAttr("tag")
+//	    ...
+//	})
+//
+// The 'formatid' attribute is handled by the parser and is allowed in every element.
+// RegisterWriterElement is intended to be called from init funcs and panics if the name
+// is already used by a standard or a registered element.
+func RegisterWriterElement(name string, constructor ElementWriterConstructor) {
+	registerElement(name, func(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
+		return createWriterElement(node, formatFromParent, formats, cfg, constructor)
+	})
+}
+
+// RegisterDispatcherElement makes name a config element of the outputs section holding child
+// outputs, like 'filter' or 'splitter'. The child outputs are created by the parser, all
+// other child elements are available to the constructor with ConfigElement.Children. The
+// dispatcher created by the constructor decides which messages reach the child outputs.
+//
+// RegisterDispatcherElement is intended to be called from init funcs and panics if the name
+// is already used by a standard or a registered element.
+func RegisterDispatcherElement(name string, constructor ElementDispatcherConstructor) {
+	registerElement(name, func(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
+		return createDispatcherElement(node, formatFromParent, formats, cfg, constructor)
+	})
+	dispatcherElements[name] = true
+}
+
+func registerElement(name string, constructor func(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error)) {
+	if len(name) == 0 {
+		panic("config element name can not be empty")
+	}
+	if _, ok := elementMap[name]; ok {
+		panic(fmt.Sprintf("duplicate config element: %s", name))
+	}
+	elementMap[name] = elementMapEntry{constructor}
+}
+
+// isOutputElement returns true if the name is a standard or a registered element of the outputs section.
+func isOutputElement(name string) bool {
+	_, ok := elementMap[name]
+	return ok
+}
+
+// ConfigElement gives the constructor of a registered element access to the element:
+// its attributes, child elements and formats.
+type ConfigElement struct {
+	node     *xmlNode
+	children []*xmlNode
+	format   *formatter
+	formats  map[string]*formatter
+	cfg      *CfgParseParams
+}
+
+func newConfigElement(node *xmlNode, children []*xmlNode, formatFromParent *formatter, formats map[string]*formatter,
+	cfg *CfgParseParams) (*ConfigElement, error) {
+	format, err := getCurrentFormat(node, formatFromParent, formats)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigElement{node, children, format, formats, cfg}, nil
+}
+
+// Name returns the name of the element.
+func (element *ConfigElement) Name() string {
+	return element.node.name
+}
+
+// Value returns the text content of the element.
+func (element *ConfigElement) Value() string {
+	return element.node.value
+}
+
+// Validating returns true if the element is created to validate or inspect a config. Constructors
+// should then check the element as usual, but neither open files or connections nor start goroutines.
+func (element *ConfigElement) Validating() bool {
+	return element.cfg.validating()
+}
+
+// CheckAttrs returns an error if the element has an attribute with a name not in the list.
+// The 'formatid' attribute is always allowed.
+func (element *ConfigElement) CheckAttrs(names ...string) error {
+	return checkUnexpectedAttribute(element.node, append(names, outputFormatID)...)
+}
+
+// CheckChildren returns an error if the element has a child element, returned by Children,
+// with a name not in the list.
+func (element *ConfigElement) CheckChildren(names ...string) error {
+	for _, child := range element.children {
+		if !stringInSlice(child.name, names) {
+			return locateError(child, newUnexpectedChildElementError(child.name))
+		}
+	}
+	return nil
+}
+
+// Attr returns the value of an attribute and whether it is set.
+func (element *ConfigElement) Attr(name string) (string, bool) {
+	value, ok := element.node.attributes[name]
+	return value, ok
+}
+
+// StringAttr returns the value of an attribute or defaultValue if the attribute is not set.
+func (element *ConfigElement) StringAttr(name, defaultValue string) string {
+	if value, ok := element.node.attributes[name]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+// RequiredAttr returns the value of an attribute or an error if the attribute is not set.
+func (element *ConfigElement) RequiredAttr(name string) (string, error) {
+	value, ok := element.node.attributes[name]
+	if !ok {
+		return "", newMissingArgumentError(element.node.name, name)
+	}
+	return value, nil
+}
+
+// IntAttr returns the value of an integer attribute or defaultValue if the attribute is not set.
+func (element *ConfigElement) IntAttr(name string, defaultValue int) (int, error) {
+	value, ok := element.node.attributes[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, element.attrError(name, value, "an integer")
+	}
+	return result, nil
+}
+
+// BoolAttr returns the value of a boolean attribute or defaultValue if the attribute is not set.
+func (element *ConfigElement) BoolAttr(name string, defaultValue bool) (bool, error) {
+	value, ok := element.node.attributes[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, element.attrError(name, value, "a boolean")
+	}
+	return result, nil
+}
+
+// DurationAttr returns the value of a duration attribute, like '1m30s', or defaultValue if
+// the attribute is not set.
+func (element *ConfigElement) DurationAttr(name string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := element.node.attributes[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, element.attrError(name, value, "a duration")
+	}
+	return result, nil
+}
+
+// LevelsAttr returns the levels listed in an attribute, like 'error,critical', or nil if
+// the attribute is not set.
+func (element *ConfigElement) LevelsAttr(name string) ([]LogLevel, error) {
+	value, ok := element.node.attributes[name]
+	if !ok {
+		return nil, nil
+	}
+	levels, err := parseLevels(value)
+	if err != nil {
+		return nil, fmt.Errorf("attribute '%s' of '%s': %v", name, element.node.name, err)
+	}
+	return levels, nil
+}
+
+func (element *ConfigElement) attrError(name, value, expected string) error {
+	return fmt.Errorf("attribute '%s' of '%s' must be %s, got '%s'", name, element.node.name, expected, value)
+}
+
+// Children returns the child elements of the element, except the child outputs of dispatcher
+// elements. Child elements inherit the format of the element.
+func (element *ConfigElement) Children() []*ConfigElement {
+	children := make([]*ConfigElement, 0, len(element.children))
+	for _, child := range element.children {
+		children = append(children, &ConfigElement{child, child.children, element.format, element.formats, element.cfg})
+	}
+	return children
+}
+
+// Format returns the format of the element: the one referenced by its 'formatid' attribute
+// or the one inherited from the parent element.
+func (element *ConfigElement) Format() *formatter {
+	return element.format
+}
+
+// FormatByID returns a format declared in the config or a predefined 'std:' format.
+func (element *ConfigElement) FormatByID(id string) (*formatter, error) {
+	if format, ok := element.formats[id]; ok {
+		return format, nil
+	}
+	if format, ok := predefinedFormats[id]; ok {
+		return format, nil
+	}
+	return nil, errors.New("formatid = '" + id + "' doesn't exist")
+}
+
+func createWriterElement(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams,
+	constructor ElementWriterConstructor) (interface{}, error) {
+	element, err := newConfigElement(node, node.children, formatFromParent, formats, cfg)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := constructor(element)
+	if err != nil {
+		return nil, err
+	}
+	if writer == nil {
+		return nil, fmt.Errorf("'%s' element created no writer", node.name)
+	}
+	return NewFormattedWriter(&elementWriter{writer, node}, element.format)
+}
+
+func createDispatcherElement(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams,
+	constructor ElementDispatcherConstructor) (interface{}, error) {
+	outputsNode := &xmlNode{name: node.name, attributes: node.attributes, value: node.value, nodePosition: node.nodePosition}
+	var children []*xmlNode
+	for _, child := range node.children {
+		if isOutputElement(child.name) {
+			outputsNode.add(child)
+		} else {
+			children = append(children, child)
+		}
+	}
+	if !outputsNode.hasChildren() {
+		return nil, errNodeMustHaveChildren
+	}
+
+	element, err := newConfigElement(node, children, formatFromParent, formats, cfg)
+	if err != nil {
+		return nil, err
+	}
+	impl, err := constructor(element)
+	if err != nil {
+		return nil, err
+	}
+	if impl == nil {
+		return nil, fmt.Errorf("'%s' element created no dispatcher", node.name)
+	}
+
+	receivers, err := createInnerReceivers(outputsNode, element.format, formats, cfg)
+	if err != nil {
+		if closer, ok := impl.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
+	}
+	disp, err := newElementDispatcher(element.format, receivers, impl, node)
+	if err != nil {
+		closeReceivers(receivers)
+		return nil, err
+	}
+	return disp, nil
+}
+
+// elementWriter keeps the config element of a registered writer to describe it in configs.
+type elementWriter struct {
+	io.Writer
+	node *xmlNode
+}
+
+func (writer *elementWriter) Flush() {
+	if flusher, ok := writer.Writer.(flusherInterface); ok {
+		flusher.Flush()
+	}
+}
+
+func (writer *elementWriter) Close() error {
+	if closer, ok := writer.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
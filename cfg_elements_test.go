@@ -0,0 +1,205 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testElementBuffers are the outputs of 'testsink' elements, by name.
+var testElementBuffers = make(map[string]*bytes.Buffer)
+
+// testLevelRouter passes messages of the routed levels to the child output with the given index
+// and all other messages to all child outputs.
+type testLevelRouter struct {
+	routes map[LogLevel]int
+	closed bool
+}
+
+func (router *testLevelRouter) Dispatch(message string, level LogLevel, context LogContextInterface, outputs ElementOutputs) error {
+	if i, ok := router.routes[level]; ok {
+		outputs.DispatchTo(i, message, level, context)
+		return nil
+	}
+	outputs.Dispatch(message, level, context)
+	return nil
+}
+
+func (router *testLevelRouter) Close() error {
+	router.closed = true
+	return nil
+}
+
+var lastTestLevelRouter *testLevelRouter
+
+func init() {
+	RegisterWriterElement("testsink", func(element *ConfigElement) (io.Writer, error) {
+		if err := element.CheckAttrs("name", "prefix", "capacity", "enabled", "timeout", "token"); err != nil {
+			return nil, err
+		}
+		name, err := element.RequiredAttr("name")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := element.IntAttr("capacity", 10); err != nil {
+			return nil, err
+		}
+		if _, err := element.BoolAttr("enabled", true); err != nil {
+			return nil, err
+		}
+		if _, err := element.DurationAttr("timeout", time.Second); err != nil {
+			return nil, err
+		}
+		buf := new(bytes.Buffer)
+		buf.WriteString(element.StringAttr("prefix", ""))
+		testElementBuffers[name] = buf
+		return buf, nil
+	})
+
+	RegisterDispatcherElement("testrouter", func(element *ConfigElement) (ElementDispatcher, error) {
+		if err := element.CheckAttrs(); err != nil {
+			return nil, err
+		}
+		if err := element.CheckChildren("route"); err != nil {
+			return nil, err
+		}
+		router := &testLevelRouter{routes: make(map[LogLevel]int)}
+		for _, route := range element.Children() {
+			if err := route.CheckAttrs("levels", "to"); err != nil {
+				return nil, err
+			}
+			levels, err := route.LevelsAttr("levels")
+			if err != nil {
+				return nil, err
+			}
+			to, err := route.IntAttr("to", 0)
+			if err != nil {
+				return nil, err
+			}
+			for _, level := range levels {
+				router.routes[level] = to
+			}
+		}
+		lastTestLevelRouter = router
+		return router, nil
+	})
+}
+
+const elementsTestConfig = `<seelog type="sync">
+	<outputs formatid="msg">
+		<testrouter>
+			<route levels="error,critical" to="1"/>
+			<testsink name="all" prefix="&gt;" capacity="5" timeout="1m0s" token="secret"/>
+			<testsink name="errors" formatid="level"/>
+		</testrouter>
+	</outputs>
+	<formats>
+		<format id="msg" format="%Msg;"/>
+		<format id="level" format="%l:%Msg;"/>
+	</formats>
+</seelog>`
+
+func TestRegisteredElements(t *testing.T) {
+	config, err := configFromReader(strings.NewReader(elementsTestConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	context := &inspectContext{"main.main", "main.go"}
+	for _, level := range []LogLevel{InfoLvl, ErrorLvl, DebugLvl} {
+		config.RootDispatcher.Dispatch(level.String(), level, context, func(err error) { t.Error(err) })
+	}
+	if err := config.RootDispatcher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if all := testElementBuffers["all"].String(); all != ">info;debug;" {
+		t.Errorf("unexpected 'all' output %q", all)
+	}
+	if errs := testElementBuffers["errors"].String(); errs != "i:info;e:error;d:debug;" {
+		t.Errorf("unexpected 'errors' output %q", errs)
+	}
+	if !lastTestLevelRouter.closed {
+		t.Error("element dispatcher was not closed")
+	}
+
+	node, err := marshalConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marshaled := string(node.marshalIndent("\t"))
+	for _, expected := range []string{
+		`<testrouter>`,
+		`<route levels="error,critical" to="1"/>`,
+		`<testsink capacity="5" name="all" prefix="&gt;" timeout="1m0s" token="********"/>`,
+		`<testsink formatid="level" name="errors"/>`,
+	} {
+		if !strings.Contains(marshaled, expected) {
+			t.Errorf("serialized config does not contain %s:\n%s", expected, marshaled)
+		}
+	}
+}
+
+func TestRegisteredElementErrors(t *testing.T) {
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{`<testsink/>`, "/seelog/outputs/testsink: Output 'testsink' has no 'name' attribute"},
+		{`<testsink name="x" capacity="many"/>`, "attribute 'capacity' of 'testsink' must be an integer, got 'many'"},
+		{`<testsink name="x" enabled="maybe"/>`, "attribute 'enabled' of 'testsink' must be a boolean, got 'maybe'"},
+		{`<testsink name="x" timeout="1"/>`, "attribute 'timeout' of 'testsink' must be a duration, got '1'"},
+		{`<testsink name="x" size="1"/>`, "unexpected attribute"},
+		{`<testrouter><route levels="error"/></testrouter>`, "must have children"},
+		{`<testrouter><rule/><console/></testrouter>`, "/seelog/outputs/testrouter/rule: "},
+		{`<testrouter><route levels="loud"/><console/></testrouter>`, "attribute 'levels' of 'route'"},
+	}
+	for _, test := range tests {
+		config := "<seelog><outputs>" + test.config + "</outputs></seelog>"
+		_, err := configFromReader(strings.NewReader(config))
+		if err == nil {
+			t.Errorf("%s: expected an error", test.config)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error containing %q, got %q", test.config, test.err, err)
+		}
+	}
+}
+
+func TestRegisterElementDuplicate(t *testing.T) {
+	for _, name := range []string{"file", "testsink"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering '%s' to panic", name)
+				}
+			}()
+			RegisterWriterElement(name, func(*ConfigElement) (io.Writer, error) { return nil, nil })
+		}()
+	}
+}
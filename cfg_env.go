@@ -0,0 +1,285 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables overriding the configs loaded by the LoggerFrom*Config* funcs and
+// the Default logger. See the 'Environment variables' section of the package documentation.
+const (
+	MinLevelEnvVariable   = "SEELOG_MINLEVEL"
+	MaxLevelEnvVariable   = "SEELOG_MAXLEVEL"
+	LevelsEnvVariable     = "SEELOG_LEVELS"
+	ExceptionsEnvVariable = "SEELOG_EXCEPTIONS"
+	FormatEnvVariable     = "SEELOG_FORMAT"
+)
+
+// envFormatID is the id of the format set by a format string in SEELOG_FORMAT.
+const envFormatID = "env:format"
+
+// envOverrideVariables lists the override variables in the order they are applied.
+var envOverrideVariables = []string{
+	MinLevelEnvVariable,
+	MaxLevelEnvVariable,
+	LevelsEnvVariable,
+	ExceptionsEnvVariable,
+	FormatEnvVariable,
+}
+
+// applyEnvOverrides applies the SEELOG_* environment variables to the config and returns the
+// result along with the applied overrides, as NAME=VALUE. The config itself is not changed.
+//
+// Overrides are applied to the config element tree, so that they are checked like the rest of
+// the config, and applying them again to a config dumped with LoggerConfigAsBytes does not
+// change it. When validating, errors are collected at the root element and the wrong
+// overrides are skipped.
+func applyEnvOverrides(config *xmlNode, cfg *CfgParseParams) (*xmlNode, []string, error) {
+	if cfg != nil && cfg.IgnoreEnvOverrides {
+		return config, nil, nil
+	}
+	values := make(map[string]string)
+	var applied []string
+	for _, name := range envOverrideVariables {
+		if value := strings.TrimSpace(os.Getenv(name)); len(value) != 0 {
+			values[name] = value
+			applied = append(applied, name+"="+value)
+		}
+	}
+	if len(applied) == 0 {
+		return config, nil, nil
+	}
+
+	resolved := copyNode(config)
+	if err := overrideEnvLevels(resolved, values); err != nil && !cfg.collectError(config, err) {
+		return nil, nil, err
+	}
+	if value, ok := values[ExceptionsEnvVariable]; ok {
+		if err := overrideEnvExceptions(resolved, value); err != nil {
+			err = fmt.Errorf("%s: %v", ExceptionsEnvVariable, err)
+			if !cfg.collectError(config, err) {
+				return nil, nil, err
+			}
+		}
+	}
+	if value, ok := values[FormatEnvVariable]; ok {
+		overrideEnvFormat(resolved, value)
+	}
+	return resolved, applied, nil
+}
+
+// overrideEnvLevels replaces the level attributes of the root element. SEELOG_MINLEVEL and
+// SEELOG_MAXLEVEL replace one bound each and drop the 'levels' list, SEELOG_LEVELS replaces all.
+func overrideEnvLevels(config *xmlNode, values map[string]string) error {
+	levels, isLevels := values[LevelsEnvVariable]
+	minLevel, isMinLevel := values[MinLevelEnvVariable]
+	maxLevel, isMaxLevel := values[MaxLevelEnvVariable]
+	if isLevels && (isMinLevel || isMaxLevel) {
+		return fmt.Errorf("%s can not be combined with %s or %s", LevelsEnvVariable, MinLevelEnvVariable, MaxLevelEnvVariable)
+	}
+
+	if isLevels {
+		if _, err := parseLevels(levels); err != nil {
+			return fmt.Errorf("%s: %v", LevelsEnvVariable, err)
+		}
+		for _, attr := range constraintsAttrs {
+			delete(config.attributes, attr)
+		}
+		config.attributes[levelsID] = levels
+		return nil
+	}
+
+	for _, bound := range []struct{ variable, attr, value string }{
+		{MinLevelEnvVariable, minLevelID, minLevel},
+		{MaxLevelEnvVariable, maxLevelID, maxLevel},
+	} {
+		if len(bound.value) == 0 {
+			continue
+		}
+		if _, ok := LogLevelFromString(bound.value); !ok {
+			return fmt.Errorf("%s: unknown level '%s'", bound.variable, bound.value)
+		}
+		delete(config.attributes, levelsID)
+		config.attributes[bound.attr] = bound.value
+	}
+	return nil
+}
+
+// overrideEnvExceptions puts the exceptions of SEELOG_EXCEPTIONS before the config exceptions,
+// replacing config exceptions with the same patterns. The value is a comma-separated list of
+// 'funcpattern:minlevel', '@filepattern:minlevel' or 'funcpattern@filepattern:minlevel' entries.
+func overrideEnvExceptions(config *xmlNode, value string) error {
+	var overrides []*xmlNode
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		colon := strings.LastIndex(entry, ":")
+		if colon < 0 {
+			return fmt.Errorf("exception '%s' must be 'pattern:minlevel'", entry)
+		}
+		patterns, level := entry[:colon], entry[colon+1:]
+		if _, ok := LogLevelFromString(level); !ok {
+			return fmt.Errorf("exception '%s': unknown level '%s'", entry, level)
+		}
+		funcPattern, filePattern := patterns, "*"
+		if at := strings.Index(patterns, "@"); at >= 0 {
+			funcPattern, filePattern = patterns[:at], patterns[at+1:]
+		}
+		if len(funcPattern) == 0 {
+			funcPattern = "*"
+		}
+		if len(filePattern) == 0 {
+			filePattern = "*"
+		}
+		constraints, err := NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		if err != nil {
+			return err
+		}
+		if _, err := NewLogLevelException(funcPattern, filePattern, constraints); err != nil {
+			return fmt.Errorf("exception '%s': %v", entry, err)
+		}
+
+		exception := newNode()
+		exception.name = exceptionID
+		exception.attributes[funcPatternID] = funcPattern
+		exception.attributes[filePatternID] = filePattern
+		exception.attributes[minLevelID] = level
+		overrides = append(overrides, exception)
+	}
+
+	var exceptions *xmlNode
+	for _, child := range config.children {
+		if child.name == exceptionsID {
+			exceptions = child
+			break
+		}
+	}
+	if exceptions == nil {
+		exceptions = newNode()
+		exceptions.name = exceptionsID
+		exceptions.nodePosition = config.nodePosition
+		config.add(exceptions)
+	}
+
+	children := overrides
+	for _, child := range exceptions.children {
+		if !overridesException(overrides, child) {
+			children = append(children, child)
+		}
+	}
+	exceptions.children = children
+	return nil
+}
+
+func overridesException(overrides []*xmlNode, exception *xmlNode) bool {
+	funcPattern, filePattern := exception.attributes[funcPatternID], exception.attributes[filePatternID]
+	if len(funcPattern) == 0 {
+		funcPattern = "*"
+	}
+	if len(filePattern) == 0 {
+		filePattern = "*"
+	}
+	for _, override := range overrides {
+		if override.attributes[funcPatternID] == funcPattern && override.attributes[filePatternID] == filePattern {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideEnvFormat makes all the outputs use the format of SEELOG_FORMAT: a format id declared
+// in the config, a predefined 'std:' format or, if the value contains '%', a format string.
+func overrideEnvFormat(config *xmlNode, value string) {
+	id := value
+	if strings.Contains(value, "%") {
+		id = envFormatID
+		var formats *xmlNode
+		for _, child := range config.children {
+			if child.name == formatsID {
+				formats = child
+				break
+			}
+		}
+		if formats == nil {
+			formats = newNode()
+			formats.name = formatsID
+			formats.nodePosition = config.nodePosition
+			config.add(formats)
+		}
+		children := formats.children[:0]
+		for _, child := range formats.children {
+			if child.attributes[formatKeyAttrID] != envFormatID {
+				children = append(children, child)
+			}
+		}
+		format := newNode()
+		format.name = formatID
+		format.attributes[formatKeyAttrID] = envFormatID
+		format.attributes[formatAttrID] = value
+		formats.children = append(children, format)
+	}
+
+	for _, child := range config.children {
+		if child.name == outputsID {
+			clearFormatIDs(child)
+			child.attributes[outputFormatID] = id
+			return
+		}
+	}
+	// Configs without outputs log to the console.
+	console := newNode()
+	console.name = consoleWriterID
+	outputs := newNode()
+	outputs.name = outputsID
+	outputs.nodePosition = config.nodePosition
+	outputs.attributes[outputFormatID] = id
+	outputs.add(console)
+	config.add(outputs)
+}
+
+// clearFormatIDs removes the format ids of the output elements under the node. Only the elements
+// holding other outputs are walked into, so the contents of custom receivers and registered
+// writers, which may use 'formatid' for their own purposes, stay as they are.
+func clearFormatIDs(node *xmlNode) {
+	for _, child := range node.children {
+		if !isOutputElement(child.name) {
+			continue
+		}
+		delete(child.attributes, outputFormatID)
+		if holdsOutputs(child.name) {
+			clearFormatIDs(child)
+		}
+	}
+}
+
+// holdsOutputs returns true if the element has output elements as children.
+func holdsOutputs(name string) bool {
+	switch name {
+	case splitterDispatcherID, filterDispatcherID, redactDispatcherID, bufferedWriterID:
+		return true
+	}
+	return dispatcherElements[name]
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// setEnvOverrides sets the override variables for a test and returns a func restoring them.
+func setEnvOverrides(values map[string]string) func() {
+	saved := make(map[string]string)
+	for _, name := range envOverrideVariables {
+		saved[name] = os.Getenv(name)
+		os.Setenv(name, values[name])
+	}
+	return func() {
+		for name, value := range saved {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	config := `<seelog minlevel="info" maxlevel="error">
+	<exceptions>
+		<exception funcpattern="main.*" minlevel="error"/>
+		<exception filepattern="*db.go" minlevel="warn"/>
+	</exceptions>
+	<outputs formatid="main">
+		<console formatid="short"/>
+	</outputs>
+	<formats>
+		<format id="main" format="%Msg%n"/>
+		<format id="short" format="%l %Msg%n"/>
+	</formats>
+</seelog>`
+
+	tests := []struct {
+		env      map[string]string
+		expected string
+	}{
+		{nil, `<seelog maxlevel="error" minlevel="info">
+	<exceptions>
+		<exception funcpattern="main.*" minlevel="error"/>
+		<exception filepattern="*db.go" minlevel="warn"/>
+	</exceptions>
+	<formats>
+		<format format="%Msg%n" id="main"/>
+		<format format="%l %Msg%n" id="short"/>
+	</formats>
+	<outputs formatid="main">
+		<console formatid="short"/>
+	</outputs>
+</seelog>`},
+		{map[string]string{
+			MinLevelEnvVariable:   "debug",
+			ExceptionsEnvVariable: "main.*:trace, pkg.*@*api.go:off",
+			FormatEnvVariable:     "std:json",
+		}, `<seelog maxlevel="error" minlevel="debug">
+	<exceptions>
+		<exception funcpattern="main.*"/>
+		<exception filepattern="*api.go" funcpattern="pkg.*" levels="off"/>
+		<exception filepattern="*db.go" minlevel="warn"/>
+	</exceptions>
+	<outputs formatid="std:json">
+		<console/>
+	</outputs>
+</seelog>`},
+		{map[string]string{
+			LevelsEnvVariable: "info,critical",
+			FormatEnvVariable: "%Level: %Msg",
+		}, `<seelog levels="info,critical">
+	<exceptions>
+		<exception funcpattern="main.*" minlevel="error"/>
+		<exception filepattern="*db.go" minlevel="warn"/>
+	</exceptions>
+	<formats>
+		<format format="%Level: %Msg" id="env:format"/>
+	</formats>
+	<outputs formatid="env:format">
+		<console/>
+	</outputs>
+</seelog>`},
+	}
+	for _, test := range tests {
+		restore := setEnvOverrides(test.env)
+		parsed, err := configFromReader(strings.NewReader(config))
+		if err != nil {
+			restore()
+			t.Errorf("%v: %s", test.env, err)
+			continue
+		}
+		node, err := marshalConfig(parsed)
+		if err != nil {
+			restore()
+			t.Fatal(err)
+		}
+		marshaled := strings.TrimSpace(string(node.marshalIndent("\t")))
+		if marshaled != test.expected {
+			t.Errorf("%v: expected\n%s\ngot\n%s", test.env, test.expected, marshaled)
+		}
+
+		// Overrides applied to a dumped config do not change it.
+		reparsed, err := configFromReader(strings.NewReader(marshaled))
+		if err != nil {
+			t.Errorf("%v: cannot parse the dumped config: %s", test.env, err)
+		} else if node, _ := marshalConfig(reparsed); strings.TrimSpace(string(node.marshalIndent("\t"))) != marshaled {
+			t.Errorf("%v: overrides changed the dumped config", test.env)
+		}
+		restore()
+	}
+}
+
+func TestEnvOverridesDefaultAndIgnored(t *testing.T) {
+	defer setEnvOverrides(map[string]string{LevelsEnvVariable: "error", FormatEnvVariable: "%Msg"})()
+
+	config, err := configFromReader(strings.NewReader(`<seelog/>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.IsAllowed(InfoLvl, &inspectContext{}) || !config.IsAllowed(ErrorLvl, &inspectContext{}) {
+		t.Error("expected SEELOG_LEVELS to be applied to an empty config")
+	}
+	if len(config.EnvOverrides) != 2 {
+		t.Errorf("unexpected overrides %v", config.EnvOverrides)
+	}
+
+	config, err = configFromReaderWithConfig(strings.NewReader(`<seelog/>`), &CfgParseParams{IgnoreEnvOverrides: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.IsAllowed(InfoLvl, &inspectContext{}) || len(config.EnvOverrides) != 0 {
+		t.Error("expected the overrides to be ignored")
+	}
+}
+
+func TestEnvOverrideErrors(t *testing.T) {
+	tests := []struct {
+		env map[string]string
+		err string
+	}{
+		{map[string]string{MinLevelEnvVariable: "loud"}, "SEELOG_MINLEVEL: unknown level 'loud'"},
+		{map[string]string{LevelsEnvVariable: "info", MaxLevelEnvVariable: "error"}, "SEELOG_LEVELS can not be combined"},
+		{map[string]string{ExceptionsEnvVariable: "main.*"}, "SEELOG_EXCEPTIONS: exception 'main.*' must be 'pattern:minlevel'"},
+		{map[string]string{ExceptionsEnvVariable: "main-*:info"}, "SEELOG_EXCEPTIONS: exception 'main-*:info'"},
+		{map[string]string{FormatEnvVariable: "missing"}, "formatid = 'missing' doesn't exist"},
+	}
+	for _, test := range tests {
+		restore := setEnvOverrides(test.env)
+		_, err := configFromReader(strings.NewReader(`<seelog/>`))
+		restore()
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%v: expected error containing %q, got %v", test.env, test.err, err)
+		}
+	}
+}
+
+func TestValidateConfigEnvOverrideErrors(t *testing.T) {
+	restore := setEnvOverrides(map[string]string{
+		MinLevelEnvVariable:   "loud",
+		ExceptionsEnvVariable: "main.*",
+	})
+	defer restore()
+
+	err := ValidateConfigAsString(`<seelog>
+	<outputs>
+		<console unknown="1"/>
+	</outputs>
+</seelog>`, nil)
+	errs, ok := err.(ConfigErrors)
+	if !ok {
+		t.Fatalf("expected ConfigErrors, got %T: %v", err, err)
+	}
+	expected := []string{
+		"line 1, column 1: /seelog: SEELOG_MINLEVEL: unknown level 'loud'",
+		"line 1, column 1: /seelog: SEELOG_EXCEPTIONS: exception 'main.*' must be 'pattern:minlevel'",
+	}
+	if len(errs) != len(expected)+1 {
+		t.Fatalf("expected %d errors, got %d:\n%v", len(expected)+1, len(errs), errs)
+	}
+	for i, message := range expected {
+		if errs[i].Error() != message {
+			t.Errorf("error %d: expected %q, got %q", i, message, errs[i])
+		}
+	}
+	if errs[2].Path != "/seelog/outputs/console" {
+		t.Errorf("expected the config error after the override ones, got: %v", errs[2])
+	}
+}
+
+func TestEnvFormatKeepsCustomElementContents(t *testing.T) {
+	config, err := unmarshalConfig(strings.NewReader(`<seelog>
+	<outputs formatid="main">
+		<filter levels="error" formatid="main">
+			<console formatid="main"/>
+		</filter>
+		<custom name="env-test" formatid="main">
+			<template formatid="own"/>
+		</custom>
+	</outputs>
+</seelog>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := setEnvOverrides(map[string]string{FormatEnvVariable: "std:json"})
+	resolved, _, err := applyEnvOverrides(config, nil)
+	restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<seelog>
+	<outputs formatid="std:json">
+		<filter levels="error">
+			<console/>
+		</filter>
+		<custom name="env-test">
+			<template formatid="own"/>
+		</custom>
+	</outputs>
+</seelog>`
+	if marshaled := strings.TrimSpace(string(resolved.marshalIndent("\t"))); marshaled != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, marshaled)
+	}
+}
@@ -26,11 +26,15 @@ package seelog
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 var (
 	errNodeMustHaveChildren   = errors.New("node must have children")
 	errNodeCannotHaveChildren = errors.New("node cannot have children")
+	// errConfigErrorsCollected is returned by the constructors of elements with errors collected by validation.
+	errConfigErrorsCollected = errors.New("config has errors")
 )
 
 type unexpectedChildElementError struct {
@@ -59,3 +63,55 @@ func newUnexpectedAttributeError(nodeName, attr string) *unexpectedAttributeErro
 	custmsg := nodeName + " has unexpected attribute: " + attr
 	return &unexpectedAttributeError{baseError{message: custmsg}}
 }
+
+// ConfigError is a config problem with the place of the element which caused it.
+type ConfigError struct {
+	File   string // Name of the config file, if known
+	Line   int    // Line of the element in an XML config, starting from 1. 0 - unknown
+	Column int    // Column of the element in an XML config, starting from 1. 0 - unknown
+	Path   string // Path of the element, like '/seelog/outputs/file[2]' in XML or '$.seelog.outputs.file[1]' in JSON configs
+	Err    error
+}
+
+func (err *ConfigError) Error() string {
+	position := err.Path
+	if err.Line > 0 {
+		if len(err.File) != 0 {
+			position = fmt.Sprintf("%s:%d:%d: %s", err.File, err.Line, err.Column, err.Path)
+		} else {
+			position = fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Path)
+		}
+	} else if len(err.File) != 0 {
+		position = err.File + ": " + err.Path
+	}
+	return fmt.Sprintf("%s: %v", position, err.Err)
+}
+
+// Unwrap returns the error without the place.
+func (err *ConfigError) Unwrap() error {
+	return err.Err
+}
+
+// ConfigErrors lists all the problems found in a config by the Validate functions.
+type ConfigErrors []*ConfigError
+
+func (errs ConfigErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// locateError adds the place of the node to the error, unless the error was
+// located by a nested node already or the place of the node is unknown.
+func locateError(node *xmlNode, err error) error {
+	if err == nil || err == errConfigErrorsCollected || len(node.location) == 0 {
+		return err
+	}
+	switch err.(type) {
+	case *ConfigError, ConfigErrors:
+		return err
+	}
+	return &ConfigError{File: node.file, Line: node.line, Column: node.column, Path: node.location, Err: err}
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultFormatName names the format of outputs that reference no format in explanations.
+const defaultFormatName = "(default)"
+
+// ConfigInspector answers questions about a config without creating a logger from it:
+// how its outputs tree is resolved and whether it lets a message through.
+type ConfigInspector struct {
+	config *configForParsing
+}
+
+// InspectConfigAsFile parses a config file for inspection. If the config is invalid, the
+// returned error is ConfigErrors, as returned by ValidateConfigAsFile. Like validation,
+// inspection opens no files or sockets and creates no custom receivers.
+func InspectConfigAsFile(fileName string, parserParams *CfgParseParams) (*ConfigInspector, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config, err := unmarshalConfigFile(file, fileName)
+	if err != nil {
+		return nil, ConfigErrors{{File: fileName, Err: err}}
+	}
+	setNodeFile(config, fileName)
+	return inspectRootNode(config, filepath.Dir(fileName), parserParams)
+}
+
+// InspectConfigAsBytes parses a config for inspection like InspectConfigAsFile does.
+func InspectConfigAsBytes(data []byte, parserParams *CfgParseParams) (*ConfigInspector, error) {
+	config, err := unmarshalConfigFile(bytes.NewReader(data), "")
+	if err != nil {
+		return nil, ConfigErrors{toConfigError(err)}
+	}
+	return inspectRootNode(config, "", parserParams)
+}
+
+// InspectConfigAsString parses a config for inspection like InspectConfigAsFile does.
+func InspectConfigAsString(data string, parserParams *CfgParseParams) (*ConfigInspector, error) {
+	return InspectConfigAsBytes([]byte(data), parserParams)
+}
+
+func inspectRootNode(config *xmlNode, baseDir string, parserParams *CfgParseParams) (*ConfigInspector, error) {
+	cfg := newValidationParams(parserParams)
+	conf, err := configFromRootNode(config, baseDir, cfg)
+	if err != nil {
+		if errs, ok := err.(ConfigErrors); ok {
+			return nil, errs
+		}
+		return nil, ConfigErrors{toConfigError(err)}
+	}
+	conf.RootDispatcher.Close()
+	return &ConfigInspector{conf}, nil
+}
+
+// LevelDecision tells whether a config lets a message through and which rule decided it.
+type LevelDecision struct {
+	Allowed bool
+
+	// Exception is the position of the deciding exception in the config, starting at 1.
+	// It is 0 if no exception matched and the general constraints decided.
+	Exception int
+
+	// Rule describes the deciding rule.
+	Rule string
+}
+
+func (decision LevelDecision) String() string {
+	verdict := "allowed"
+	if !decision.Allowed {
+		verdict = "denied"
+	}
+	return verdict + " by " + decision.Rule
+}
+
+// TestLevel tells whether a message with the given level, logged from the given function
+// and file, passes the constraints and exceptions of the config. Func is a full function
+// name like 'main.handle' and file is a path as reported by the runtime.
+//
+// Only the logger level rules are checked: filter elements in the outputs tree may still
+// drop an allowed message.
+func (inspector *ConfigInspector) TestLevel(funcName, file string, level LogLevel) LevelDecision {
+	context := &inspectContext{funcName, file}
+	decision := LevelDecision{
+		Allowed: inspector.config.IsAllowed(level, context),
+		Rule:    "general constraints (" + constraintsString(inspector.config.Constraints) + ")",
+	}
+	for i, exception := range inspector.config.Exceptions {
+		if exception.MatchesContext(context) {
+			decision.Exception = i + 1
+			decision.Rule = fmt.Sprintf("exception %d (%s)", i+1, exceptionString(exception))
+			break
+		}
+	}
+	return decision
+}
+
+// Explain describes the config as it is resolved by the parser: the logger type, the applied
+// SEELOG_* environment overrides, the level rules in the order they are checked, the formats
+// in use, including the predefined 'std:' ones, and the outputs tree with the effective format
+// of every receiver. Secrets are masked.
+func (inspector *ConfigInspector) Explain() (string, error) {
+	root, err := marshalConfig(inspector.config)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	logType := loggerTypeToStringRepresentations[inspector.config.LogType]
+	fmt.Fprintf(&buf, "Logger: %s%s\n", logType, attributesString(root, minLevelID, maxLevelID, levelsID, loggerTypeFromStringAttr))
+
+	if len(inspector.config.EnvOverrides) != 0 {
+		fmt.Fprintln(&buf, "Environment overrides:")
+		for _, override := range inspector.config.EnvOverrides {
+			fmt.Fprintf(&buf, "  %s\n", override)
+		}
+	}
+
+	fmt.Fprintln(&buf, "Level rules (the first matching exception decides, the general constraints apply otherwise):")
+	for i, exception := range inspector.config.Exceptions {
+		fmt.Fprintf(&buf, "  %d. exception %s\n", i+1, exceptionString(exception))
+	}
+	fmt.Fprintf(&buf, "  general constraints: %s\n", constraintsString(inspector.config.Constraints))
+
+	formats := map[string]string{defaultFormatName: DefaultMsgFormat}
+	for _, child := range root.children {
+		if child.name != formatsID {
+			continue
+		}
+		for _, format := range child.children {
+			formats[format.attributes[formatKeyAttrID]] = format.attributes[formatAttrID]
+		}
+	}
+	used := make(map[string]bool)
+	var outputs bytes.Buffer
+	for _, child := range root.children {
+		if child.name == outputsID {
+			explainOutput(&outputs, child, defaultFormatName, true, 1, used)
+		}
+	}
+
+	fmt.Fprintln(&buf, "Formats:")
+	ids := make([]string, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		format, ok := formats[id]
+		if !ok {
+			format = predefinedFormats[id].fmtStringOriginal
+		}
+		fmt.Fprintf(&buf, "  %s: %q\n", id, format)
+	}
+
+	fmt.Fprintln(&buf, "Outputs:")
+	buf.Write(outputs.Bytes())
+	return buf.String(), nil
+}
+
+// explainOutput writes an element of the outputs tree and its children. Receivers, that is
+// the children of dispatchers, are followed by their effective format.
+func explainOutput(buf *bytes.Buffer, node *xmlNode, format string, receiver bool, depth int, used map[string]bool) {
+	if id, ok := node.attributes[outputFormatID]; ok {
+		format = id
+	}
+	fmt.Fprintf(buf, "%s%s%s", strings.Repeat("  ", depth), node.name, attributesString(node, outputFormatID))
+	if receiver {
+		used[format] = true
+		fmt.Fprintf(buf, " [format %s]", format)
+	}
+	buf.WriteByte('\n')
+
+	dispatcher := node.name == outputsID || node.name == splitterDispatcherID ||
+		node.name == filterDispatcherID || node.name == redactDispatcherID || dispatcherElements[node.name]
+	for _, child := range node.children {
+		explainOutput(buf, child, format, dispatcher && isOutputElement(child.name), depth+1, used)
+	}
+}
+
+// attributesString lists the attributes of a node, except the skipped ones, sorted by name.
+func attributesString(node *xmlNode, skipped ...string) string {
+	names := make([]string, 0, len(node.attributes))
+	for name := range node.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		if stringInSlice(name, skipped) {
+			continue
+		}
+		fmt.Fprintf(&buf, " %s=%q", name, node.attributes[name])
+	}
+	return buf.String()
+}
+
+func stringInSlice(str string, slice []string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func exceptionString(exception *LogLevelException) string {
+	return fmt.Sprintf("funcpattern=%q filepattern=%q: %s",
+		exception.FuncPattern(), exception.FilePattern(), constraintsString(exception.constraints))
+}
+
+// constraintsString describes level constraints with the config attributes setting them.
+func constraintsString(constraints logLevelConstraints) string {
+	node := newNode()
+	if err := marshalConstraints(node, constraints); err != nil {
+		return fmt.Sprint(constraints)
+	}
+	if len(node.attributes) == 0 {
+		return "all levels"
+	}
+	return strings.TrimSpace(attributesString(node))
+}
+
+// inspectContext is the context of a message that is tested against a config.
+type inspectContext struct {
+	funcName string
+	fullPath string
+}
+
+func (context *inspectContext) IsValid() bool              { return true }
+func (context *inspectContext) Func() string               { return context.funcName }
+func (context *inspectContext) Line() int                  { return 0 }
+func (context *inspectContext) ShortPath() string          { return context.fullPath }
+func (context *inspectContext) FullPath() string           { return context.fullPath }
+func (context *inspectContext) FileName() string           { return filepath.Base(context.fullPath) }
+func (context *inspectContext) CallTime() time.Time        { return time.Time{} }
+func (context *inspectContext) CustomContext() interface{} { return nil }
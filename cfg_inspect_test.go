@@ -0,0 +1,118 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"strings"
+	"testing"
+)
+
+const inspectTestConfig = `<seelog minlevel="info">
+	<exceptions>
+		<exception funcpattern="main.debug*" minlevel="trace"/>
+		<exception filepattern="*noisy.go" levels="error,critical"/>
+	</exceptions>
+	<outputs formatid="main">
+		<console/>
+		<filter levels="error" formatid="std:json">
+			<smtp senderaddress="a@b.c" sendername="n" hostname="h" hostport="25" username="u" password="secret">
+				<recipient address="ops@b.c"/>
+			</smtp>
+		</filter>
+	</outputs>
+	<formats>
+		<format id="main" format="%Msg%n"/>
+	</formats>
+</seelog>`
+
+func TestConfigInspectorTestLevel(t *testing.T) {
+	inspector, err := InspectConfigAsString(inspectTestConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		funcName, file string
+		level          LogLevel
+		allowed        bool
+		exception      int
+	}{
+		{"main.debugHandler", "/src/main.go", TraceLvl, true, 1},
+		{"main.handler", "/src/noisy.go", WarnLvl, false, 2},
+		{"main.handler", "/src/noisy.go", ErrorLvl, true, 2},
+		{"main.handler", "/src/main.go", DebugLvl, false, 0},
+		{"main.handler", "/src/main.go", InfoLvl, true, 0},
+	}
+	for _, test := range tests {
+		decision := inspector.TestLevel(test.funcName, test.file, test.level)
+		if decision.Allowed != test.allowed || decision.Exception != test.exception {
+			t.Errorf("%s %s %s: got %+v, expected allowed=%v by exception %d",
+				test.funcName, test.file, test.level, decision, test.allowed, test.exception)
+		}
+	}
+
+	expected := `denied by general constraints (minlevel="info")`
+	if decision := inspector.TestLevel("main.f", "main.go", DebugLvl).String(); decision != expected {
+		t.Errorf("got %q, expected %q", decision, expected)
+	}
+}
+
+func TestConfigInspectorExplain(t *testing.T) {
+	inspector, err := InspectConfigAsString(inspectTestConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation, err := inspector.Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`Logger: asyncloop` + "\n",
+		`  1. exception funcpattern="main.debug*" filepattern="*": all levels` + "\n",
+		`  2. exception funcpattern="*" filepattern="*noisy.go": levels="error,critical"` + "\n",
+		`  general constraints: minlevel="info"` + "\n",
+		`  main: "%Msg%n"` + "\n",
+		`  std:json: `,
+		`    console [format main]` + "\n",
+		`    filter levels="error" [format std:json]` + "\n",
+		`password="********"`,
+		`        recipient address="ops@b.c"` + "\n",
+	} {
+		if !strings.Contains(explanation, expected) {
+			t.Errorf("explanation does not contain %q:\n%s", expected, explanation)
+		}
+	}
+	if strings.Contains(explanation, "secret") {
+		t.Errorf("explanation contains a secret:\n%s", explanation)
+	}
+}
+
+func TestInspectConfigErrors(t *testing.T) {
+	_, err := InspectConfigAsString(`<seelog minlevel="loud"><outputs><custom name="unknown"/></outputs></seelog>`, nil)
+	errs, ok := err.(ConfigErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected two config errors, got %v", err)
+	}
+}
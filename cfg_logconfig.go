@@ -95,6 +95,8 @@ type configForParsing struct {
 	LogType    loggerTypeFromString
 	LoggerData interface{}
 	Params     *CfgParseParams // Check cfg_parser: CfgParseParams
+
+	EnvOverrides []string // SEELOG_* environment variables applied to the config, as NAME=VALUE
 }
 
 func newFullLoggerConfig(
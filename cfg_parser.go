@@ -21,18 +21,21 @@
 // ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
 // (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
 // SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
-
 package seelog
 
 import (
+	"bufio"
 	"crypto/tls"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Names of elements of seelog config.
@@ -74,6 +77,12 @@ const (
 	customNameDataAttrPrefix         = "data-"
 	filterDispatcherID               = "filter"
 	filterLevelsAttrID               = "levels"
+	filterModeAttrID                 = "mode"
+	filterContainsAttrID             = "contains"
+	filterRegexpAttrID               = "regexp"
+	filterFuncPatternAttrID          = "funcpattern"
+	filterFilePatternAttrID          = "filepattern"
+	filterContextAttrID              = "context"
 	rollingfileWriterID              = "rollingfile"
 	rollingFileTypeAttr              = "type"
 	rollingFilePathAttr              = "filename"
@@ -85,6 +94,21 @@ const (
 	rollingFileArchivePathAttr       = "archivepath"
 	rollingFileArchiveExplodedAttr   = "archiveexploded"
 	rollingFileFullNameAttr          = "fullname"
+	rollingFileMaxAgeAttr            = "maxage"
+	rollingFileMaxTotalSizeAttr      = "maxtotalsize"
+	rollingFileArchiveAsyncAttr      = "archiveasync"
+	rollingFileArchiveQueueAttr      = "archivequeue"
+	rollingFileArchiveLevelAttr      = "archivelevel"
+	reopenIntervalAttr               = "reopeninterval"
+	rollingFileSymlinkAttr           = "symlink"
+	rollingFileHooksAttr             = "hooks"
+	dynamicFileAttr                  = "dynamic"
+	fileLockAttr                     = "lock"
+	fileSyncAttr                     = "sync"
+	fileSyncLevelAttr                = "synclevel"
+	fileSyncIntervalAttr             = "syncinterval"
+	dynamicFileMaxOpenAttr           = "maxopen"
+	dynamicFileIdleTimeoutAttr       = "idletimeout"
 	bufferedWriterID                 = "buffered"
 	bufferedSizeAttr                 = "size"
 	bufferedFlushPeriodAttr          = "flushperiod"
@@ -100,6 +124,15 @@ const (
 	connWriterReconnectOnMsgAttr     = "reconnectonmsg"
 	connWriterUseTLSAttr             = "tls"
 	connWriterInsecureSkipVerifyAttr = "insecureskipverify"
+	memoryWriterID                   = "memory"
+	memoryNameAttr                   = "name"
+	memoryCapacityAttr               = "capacity"
+	redactDispatcherID               = "redact"
+	redactPresetsAttr                = "presets"
+	redactModeAttr                   = "replace"
+	redactRuleID                     = "rule"
+	redactRulePatternAttr            = "pattern"
+	redactHashKeyAttr                = "hashkey"
 )
 
 // CustomReceiverProducer is the signature of the function CfgParseParams needs to create
@@ -130,6 +163,66 @@ type CfgParseParams struct {
 	// a custom receiver or if you frequently change custom receivers with different parameters or in any other
 	// situation where package-level registering (RegisterReceiver) is not an option for you.
 	CustomReceiverProducers map[string]CustomReceiverProducer
+
+	// RollHooks provide roll hooks for the 'hooks' attribute of rolling file writers in the
+	// scope of the config parse func. A hook name is searched in this map first and then
+	// among the hooks registered globally with RegisterRollHook.
+	RollHooks map[string]RollHook
+
+	// Variables supply values for '${NAME}' and '${NAME:-default}' references in config attributes.
+	// Names not found here are looked up with LookupVariable, if it is set, and then in the
+	// environment if EnvVariables is set. A literal '${' is written as '$${'.
+	Variables map[string]string
+
+	// LookupVariable, if not nil, supplies the values of variables that are not in Variables.
+	LookupVariable func(name string) (string, bool)
+
+	// EnvVariables makes the environment variables supply the values of variables that are
+	// found neither in Variables nor with LookupVariable.
+	EnvVariables bool
+
+	// Clock, if not nil, replaces the system clock in the created logger and in all
+	// time-dependent writers (rolling files, buffered writers). See Clock.
+	Clock Clock
+
+	// IgnoreEnvOverrides disables the SEELOG_* environment variables overriding the levels,
+	// exceptions and formats of the config. It does not affect SEELOG_PROFILE.
+	IgnoreEnvOverrides bool
+
+	// Profile selects the '<profile>' element of the config that is applied on top of the base
+	// config. If empty, the profile is taken from the SEELOG_PROFILE environment variable, and if
+	// that is empty too, the base config is used as is.
+	Profile string
+
+	// collected, if not nil, makes the parser collect config errors instead of stopping
+	// at the first one. Set by the Validate functions only.
+	collected *ConfigErrors
+}
+
+// applyClock sets the clock from parse params to a logger or writer, if needed.
+func (cfg *CfgParseParams) applyClock(target ClockSetter) {
+	if cfg != nil && cfg.Clock != nil {
+		target.SetClock(cfg.Clock)
+	}
+}
+
+// lookupVariable returns the value of a config variable.
+func (cfg *CfgParseParams) lookupVariable(name string) (string, bool) {
+	if cfg == nil {
+		return "", false
+	}
+	if value, ok := cfg.Variables[name]; ok {
+		return value, true
+	}
+	if cfg.LookupVariable != nil {
+		if value, ok := cfg.LookupVariable(name); ok {
+			return value, true
+		}
+	}
+	if cfg.EnvVariables {
+		return os.LookupEnv(name)
+	}
+	return "", false
 }
 
 func (cfg *CfgParseParams) String() string {
@@ -154,6 +247,8 @@ func init() {
 		bufferedWriterID:     {createbufferedWriter},
 		smtpWriterID:         {createSMTPWriter},
 		connWriterID:         {createconnWriter},
+		memoryWriterID:       {createMemoryWriter},
+		redactDispatcherID:   {createRedact},
 	}
 
 	err := fillPredefinedFormats()
@@ -216,6 +311,10 @@ func configFromXMLDecoderWithConfig(xmlParser *xml.Decoder, rootNode xml.Token,
 	if config == nil {
 		return nil, errors.New("xml has no content")
 	}
+	setXMLNodePaths(config, "")
+	if err := preprocessConfig(config, "", cfg); err != nil {
+		return nil, err
+	}
 
 	return configFromXMLNodeWithConfig(config, cfg)
 }
@@ -231,20 +330,88 @@ func configFromReader(reader io.Reader) (*configForParsing, error) {
 // Returns parsed config which can be used to create logger in case no errors occured.
 // Returns error if format is incorrect or anything happened.
 func configFromReaderWithConfig(reader io.Reader, cfg *CfgParseParams) (*configForParsing, error) {
-	config, err := unmarshalConfig(reader)
+	config, err := unmarshalConfigFile(reader, "")
 	if err != nil {
 		return nil, err
 	}
+	return configFromRootNode(config, "", cfg)
+}
 
+// configFromFile parses a config file. Files with the '.json' extension are parsed as JSON,
+// the format of other files is detected by the content. Included files are searched
+// relative to the directory of the file.
+func configFromFile(fileName string, cfg *CfgParseParams) (*configForParsing, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config, err := unmarshalConfigFile(file, fileName)
+	if err != nil {
+		return nil, err
+	}
+	setNodeFile(config, fileName)
+	return configFromRootNode(config, filepath.Dir(fileName), cfg)
+}
+
+// unmarshalConfigFile reads an XML or JSON config. The format is chosen by the file name
+// extension, if the file name is known, or by the content.
+func unmarshalConfigFile(reader io.Reader, fileName string) (*xmlNode, error) {
+	if strings.EqualFold(filepath.Ext(fileName), ".json") {
+		return unmarshalJSONConfig(reader)
+	}
+	bufReader := bufio.NewReader(reader)
+	if isJSONConfig(bufReader) {
+		return unmarshalJSONConfig(bufReader)
+	}
+	return unmarshalConfig(bufReader)
+}
+
+// configFromRootNode parses an unmarshaled config. Relative paths of included files are
+// resolved against baseDir.
+func configFromRootNode(config *xmlNode, baseDir string, cfg *CfgParseParams) (*configForParsing, error) {
 	if config.name != seelogConfigID {
-		return nil, errors.New("root xml tag must be '" + seelogConfigID + "'")
+		return nil, locateError(config, errors.New("root tag must be '"+seelogConfigID+"'"))
 	}
 
-	return configFromXMLNodeWithConfig(config, cfg)
+	if err := preprocessConfig(config, baseDir, cfg); err != nil {
+		return nil, locateError(config, err)
+	}
+
+	conf, err := configFromXMLNodeWithConfig(config, cfg)
+	if err != nil {
+		return nil, locateError(config, err)
+	}
+	return conf, nil
+}
+
+// isJSONConfig returns true if the config data starts with a JSON object rather than with XML.
+func isJSONConfig(reader *bufio.Reader) bool {
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return false
+		}
+		if unicode.IsSpace(r) || r == '\uFEFF' {
+			continue
+		}
+		reader.UnreadRune()
+		return r == '{'
+	}
 }
 
 func configFromXMLNodeWithConfig(config *xmlNode, cfg *CfgParseParams) (*configForParsing, error) {
-	err := checkUnexpectedAttribute(
+	config, err := resolveProfile(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	config, overrides, err := applyEnvOverrides(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkUnexpectedAttribute(
 		config,
 		minLevelID,
 		maxLevelID,
@@ -255,36 +422,36 @@ func configFromXMLNodeWithConfig(config *xmlNode, cfg *CfgParseParams) (*configF
 		adaptLoggerMaxIntervalAttr,
 		adaptLoggerCriticalMsgCountAttr,
 	)
-	if err != nil {
+	if err != nil && !cfg.collectError(config, err) {
 		return nil, err
 	}
 
 	err = checkExpectedElements(config, optionalElement(outputsID), optionalElement(formatsID), optionalElement(exceptionsID))
-	if err != nil {
+	if err != nil && !cfg.collectError(config, err) {
 		return nil, err
 	}
 
 	constraints, err := getConstraints(config)
-	if err != nil {
+	if err != nil && !cfg.collectError(config, err) {
 		return nil, err
 	}
 
-	exceptions, err := getExceptions(config)
+	exceptions, err := getExceptions(config, cfg)
 	if err != nil {
 		return nil, err
 	}
 	err = checkDistinctExceptions(exceptions)
-	if err != nil {
+	if err != nil && !cfg.collectError(config, err) {
 		return nil, err
 	}
 
-	formats, err := getFormats(config)
+	formats, err := getFormats(config, cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	dispatcher, err := getOutputsTree(config, formats, cfg)
-	if err != nil {
+	if err != nil && !cfg.collectError(config, err) {
 		// If we open several files, but then fail to parse the config, we should close
 		// those files before reporting that config is invalid.
 		if dispatcher != nil {
@@ -295,11 +462,23 @@ func configFromXMLNodeWithConfig(config *xmlNode, cfg *CfgParseParams) (*configF
 	}
 
 	loggerType, logData, err := getloggerTypeFromStringData(config)
-	if err != nil {
+	if err != nil && !cfg.collectError(config, err) {
 		return nil, err
 	}
 
-	return newFullLoggerConfig(constraints, exceptions, dispatcher, loggerType, logData, cfg)
+	if errs := cfg.collectedErrors(); errs != nil {
+		if dispatcher != nil {
+			dispatcher.Close()
+		}
+		return nil, errs
+	}
+
+	conf, err := newFullLoggerConfig(constraints, exceptions, dispatcher, loggerType, logData, cfg)
+	if err != nil {
+		return nil, err
+	}
+	conf.EnvOverrides = overrides
+	return conf, nil
 }
 
 func getConstraints(node *xmlNode) (logLevelConstraints, error) {
@@ -364,7 +543,7 @@ func parseLevels(str string) ([]LogLevel, error) {
 	return levels, nil
 }
 
-func getExceptions(config *xmlNode) ([]*LogLevelException, error) {
+func getExceptions(config *xmlNode, cfg *CfgParseParams) ([]*LogLevelException, error) {
 	var exceptions []*LogLevelException
 
 	var exceptionsNode *xmlNode
@@ -380,48 +559,59 @@ func getExceptions(config *xmlNode) ([]*LogLevelException, error) {
 	}
 
 	err := checkUnexpectedAttribute(exceptionsNode)
-	if err != nil {
+	if err != nil && !cfg.collectError(exceptionsNode, err) {
 		return nil, err
 	}
 
 	err = checkExpectedElements(exceptionsNode, multipleMandatoryElements("exception"))
-	if err != nil {
+	if err != nil && !cfg.collectError(exceptionsNode, err) {
 		return nil, err
 	}
 
 	for _, exceptionNode := range exceptionsNode.children {
-		if exceptionNode.name != exceptionID {
-			return nil, errors.New("incorrect nested element in exceptions section: " + exceptionNode.name)
-		}
-
-		err := checkUnexpectedAttribute(exceptionNode, minLevelID, maxLevelID, levelsID, funcPatternID, filePatternID)
+		exception, err := getException(exceptionNode)
 		if err != nil {
-			return nil, err
+			if cfg.collectError(exceptionNode, err) {
+				continue
+			}
+			return nil, locateError(exceptionNode, err)
 		}
 
-		constraints, err := getConstraints(exceptionNode)
-		if err != nil {
-			return nil, errors.New("incorrect " + exceptionsID + " node: " + err.Error())
-		}
+		exceptions = append(exceptions, exception)
+	}
 
-		funcPattern, isFuncPattern := exceptionNode.attributes[funcPatternID]
-		filePattern, isFilePattern := exceptionNode.attributes[filePatternID]
-		if !isFuncPattern {
-			funcPattern = "*"
-		}
-		if !isFilePattern {
-			filePattern = "*"
-		}
+	return exceptions, nil
+}
 
-		exception, err := NewLogLevelException(funcPattern, filePattern, constraints)
-		if err != nil {
-			return nil, errors.New("incorrect exception node: " + err.Error())
-		}
+func getException(exceptionNode *xmlNode) (*LogLevelException, error) {
+	if exceptionNode.name != exceptionID {
+		return nil, errors.New("incorrect nested element in exceptions section: " + exceptionNode.name)
+	}
 
-		exceptions = append(exceptions, exception)
+	err := checkUnexpectedAttribute(exceptionNode, minLevelID, maxLevelID, levelsID, funcPatternID, filePatternID)
+	if err != nil {
+		return nil, err
 	}
 
-	return exceptions, nil
+	constraints, err := getConstraints(exceptionNode)
+	if err != nil {
+		return nil, errors.New("incorrect " + exceptionsID + " node: " + err.Error())
+	}
+
+	funcPattern, isFuncPattern := exceptionNode.attributes[funcPatternID]
+	filePattern, isFilePattern := exceptionNode.attributes[filePatternID]
+	if !isFuncPattern {
+		funcPattern = "*"
+	}
+	if !isFilePattern {
+		filePattern = "*"
+	}
+
+	exception, err := NewLogLevelException(funcPattern, filePattern, constraints)
+	if err != nil {
+		return nil, errors.New("incorrect exception node: " + err.Error())
+	}
+	return exception, nil
 }
 
 func checkDistinctExceptions(exceptions []*LogLevelException) error {
@@ -443,7 +633,7 @@ func checkDistinctExceptions(exceptions []*LogLevelException) error {
 	return nil
 }
 
-func getFormats(config *xmlNode) (map[string]*formatter, error) {
+func getFormats(config *xmlNode, cfg *CfgParseParams) (map[string]*formatter, error) {
 	formats := make(map[string]*formatter, 0)
 
 	var formatsNode *xmlNode
@@ -459,37 +649,23 @@ func getFormats(config *xmlNode) (map[string]*formatter, error) {
 	}
 
 	err := checkUnexpectedAttribute(formatsNode)
-	if err != nil {
+	if err != nil && !cfg.collectError(formatsNode, err) {
 		return nil, err
 	}
 
 	err = checkExpectedElements(formatsNode, multipleMandatoryElements("format"))
-	if err != nil {
+	if err != nil && !cfg.collectError(formatsNode, err) {
 		return nil, err
 	}
 
 	for _, formatNode := range formatsNode.children {
-		if formatNode.name != formatID {
-			return nil, errors.New("incorrect nested element in " + formatsID + " section: " + formatNode.name)
-		}
-
-		err := checkUnexpectedAttribute(formatNode, formatKeyAttrID, formatID)
+		id, formatter, err := getFormat(formatNode)
 		if err != nil {
-			return nil, err
-		}
-
-		id, isID := formatNode.attributes[formatKeyAttrID]
-		formatStr, isFormat := formatNode.attributes[formatAttrID]
-		if !isID {
-			return nil, errors.New("format has no '" + formatKeyAttrID + "' attribute")
-		}
-		if !isFormat {
-			return nil, errors.New("format[" + id + "] has no '" + formatAttrID + "' attribute")
-		}
-
-		formatter, err := NewFormatter(formatStr)
-		if err != nil {
-			return nil, err
+			if !cfg.collectError(formatNode, err) {
+				return nil, locateError(formatNode, err)
+			}
+			// Outputs referencing the invalid format are still checked with the default one.
+			formatter = DefaultFormatter
 		}
 
 		formats[id] = formatter
@@ -498,6 +674,33 @@ func getFormats(config *xmlNode) (map[string]*formatter, error) {
 	return formats, nil
 }
 
+func getFormat(formatNode *xmlNode) (string, *formatter, error) {
+	if formatNode.name != formatID {
+		return "", nil, errors.New("incorrect nested element in " + formatsID + " section: " + formatNode.name)
+	}
+
+	err := checkUnexpectedAttribute(formatNode, formatKeyAttrID, formatID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	id, isID := formatNode.attributes[formatKeyAttrID]
+	formatStr, isFormat := formatNode.attributes[formatAttrID]
+	if !isID {
+		return "", nil, errors.New("format has no '" + formatKeyAttrID + "' attribute")
+	}
+	if !isFormat {
+		return id, nil, errors.New("format[" + id + "] has no '" + formatAttrID + "' attribute")
+	}
+
+	formatter, err := NewFormatter(formatStr)
+	if err != nil {
+		return id, nil, err
+	}
+	formatter.id = id
+	return id, formatter, nil
+}
+
 func getloggerTypeFromStringData(config *xmlNode) (logType loggerTypeFromString, logData interface{}, err error) {
 	logTypeStr, loggerTypeExists := config.attributes[loggerTypeFromStringAttr]
 
@@ -573,17 +776,17 @@ func getOutputsTree(config *xmlNode, formats map[string]*formatter, cfg *CfgPars
 	if outputsNode != nil {
 		err := checkUnexpectedAttribute(outputsNode, outputFormatID)
 		if err != nil {
-			return nil, err
+			return nil, locateError(outputsNode, err)
 		}
 
 		formatter, err := getCurrentFormat(outputsNode, DefaultFormatter, formats)
 		if err != nil {
-			return nil, err
+			return nil, locateError(outputsNode, err)
 		}
 
 		output, err := createSplitter(outputsNode, formatter, formats, cfg)
 		if err != nil {
-			return nil, err
+			return nil, locateError(outputsNode, err)
 		}
 
 		dispatcher, ok := output.(dispatcherInterface)
@@ -622,23 +825,50 @@ func getCurrentFormat(node *xmlNode, formatFromParent *formatter, formats map[st
 
 func createInnerReceivers(node *xmlNode, format *formatter, formats map[string]*formatter, cfg *CfgParseParams) ([]interface{}, error) {
 	var outputs []interface{}
+	failed := false
 	for _, childNode := range node.children {
-		entry, ok := elementMap[childNode.name]
-		if !ok {
-			return nil, errors.New("unnknown tag '" + childNode.name + "' in outputs section")
-		}
-
-		output, err := entry.constructor(childNode, format, formats, cfg)
+		output, err := createReceiver(childNode, format, formats, cfg)
 		if err != nil {
-			return nil, err
+			if cfg.collectError(childNode, err) {
+				failed = true
+				continue
+			}
+			closeReceivers(outputs)
+			return nil, locateError(childNode, err)
 		}
 
 		outputs = append(outputs, output)
 	}
+	if failed {
+		closeReceivers(outputs)
+		return nil, errConfigErrorsCollected
+	}
 
 	return outputs, nil
 }
 
+// closeReceivers closes the receivers created before a config error.
+func closeReceivers(receivers []interface{}) {
+	for _, receiver := range receivers {
+		switch typed := receiver.(type) {
+		case *formattedWriter:
+			if closer, ok := typed.Writer().(io.Closer); ok {
+				closer.Close()
+			}
+		case dispatcherInterface:
+			typed.Close()
+		}
+	}
+}
+
+func createReceiver(node *xmlNode, format *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
+	entry, ok := elementMap[node.name]
+	if !ok {
+		return nil, errors.New("unnknown tag '" + node.name + "' in outputs section")
+	}
+	return entry.constructor(node, format, formats, cfg)
+}
+
 func createSplitter(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
 	err := checkUnexpectedAttribute(node, outputFormatID)
 	if err != nil {
@@ -680,19 +910,21 @@ func createCustomReceiver(node *xmlNode, formatFromParent *formatter, formats ma
 		}
 	}
 
-	if node.hasChildren() {
-		return nil, errNodeCannotHaveChildren
-	}
 	customName, hasCustomName := node.attributes[customNameAttrID]
 	if !hasCustomName {
 		return nil, newMissingArgumentError(node.name, customNameAttrID)
 	}
-	currentFormat, err := getCurrentFormat(node, formatFromParent, formats)
+	element, err := newConfigElement(node, node.children, formatFromParent, formats, cfg)
 	if err != nil {
 		return nil, err
 	}
+	currentFormat := element.Format()
 	args := CustomReceiverInitArgs{
 		XmlCustomAttrs: dataCustomPrefixes,
+		Element:        element,
+	}
+	if cfg.validating() {
+		return newValidationReceiverDispatcher(currentFormat, customName, args, cfg)
 	}
 
 	if cfg != nil && cfg.CustomReceiverProducers != nil {
@@ -701,6 +933,9 @@ func createCustomReceiver(node *xmlNode, formatFromParent *formatter, formats ma
 			if err != nil {
 				return nil, err
 			}
+			if err := checkCustomReceiverArgs(rec, args); err != nil {
+				return nil, err
+			}
 			creceiver, err := NewCustomReceiverDispatcherByValue(currentFormat, rec, customName, args)
 			if err != nil {
 				return nil, err
@@ -717,7 +952,8 @@ func createCustomReceiver(node *xmlNode, formatFromParent *formatter, formats ma
 }
 
 func createFilter(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
-	err := checkUnexpectedAttribute(node, outputFormatID, filterLevelsAttrID)
+	err := checkUnexpectedAttribute(node, outputFormatID, filterLevelsAttrID, filterModeAttrID, filterContainsAttrID,
+		filterRegexpAttrID, filterFuncPatternAttrID, filterFilePatternAttrID, filterContextAttrID)
 	if err != nil {
 		return nil, err
 	}
@@ -731,26 +967,185 @@ func createFilter(node *xmlNode, formatFromParent *formatter, formats map[string
 		return nil, err
 	}
 
+	rules, err := createFilterRules(node)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := FilterInclude
+	if modeStr, ok := node.attributes[filterModeAttrID]; ok {
+		mode, ok = filterModeFromString(modeStr)
+		if !ok {
+			return nil, fmt.Errorf("invalid filter mode: '%s'", modeStr)
+		}
+	}
+
+	var levels []LogLevel
 	levelsStr, isLevels := node.attributes[filterLevelsAttrID]
-	if !isLevels {
+	if isLevels {
+		levels, err = parseLevels(levelsStr)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(rules) == 0 {
 		return nil, newMissingArgumentError(node.name, filterLevelsAttrID)
 	}
 
-	levels, err := parseLevels(levelsStr)
+	receivers, err := createInnerReceivers(node, currentFormat, formats, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	receivers, err := createInnerReceivers(node, currentFormat, formats, cfg)
+	if len(rules) == 0 && mode == FilterInclude {
+		return NewFilterDispatcher(currentFormat, receivers, levels...)
+	}
+	if isLevels && levels == nil {
+		levels = []LogLevel{}
+	}
+	return NewRuleFilterDispatcher(currentFormat, receivers, mode, levels, rules...)
+}
+
+func createFilterRules(node *xmlNode) ([]FilterRule, error) {
+	var rules []FilterRule
+	if substr, ok := node.attributes[filterContainsAttrID]; ok {
+		rules = append(rules, NewMessageContainsRule(substr))
+	}
+	if expr, ok := node.attributes[filterRegexpAttrID]; ok {
+		rule, err := NewMessageRegexpRule(expr)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if pattern, ok := node.attributes[filterFuncPatternAttrID]; ok {
+		rule, err := NewFuncPatternRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if pattern, ok := node.attributes[filterFilePatternAttrID]; ok {
+		rule, err := NewFilePatternRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if field, ok := node.attributes[filterContextAttrID]; ok {
+		eq := strings.Index(field, "=")
+		if eq == -1 {
+			return nil, fmt.Errorf("invalid filter context '%s': must be in the 'name=value' form", field)
+		}
+		rule, err := NewContextFieldRule(strings.TrimSpace(field[:eq]), strings.TrimSpace(field[eq+1:]))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func createRedact(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
+	err := checkUnexpectedAttribute(node, outputFormatID, redactPresetsAttr, redactModeAttr, redactHashKeyAttr)
+	if err != nil {
+		return nil, err
+	}
+
+	currentFormat, err := getCurrentFormat(node, formatFromParent, formats)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewFilterDispatcher(currentFormat, receivers, levels...)
+	mode, err := parseRedactMode(node, RedactMask)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []*redactRule
+	if presetsStr, ok := node.attributes[redactPresetsAttr]; ok {
+		for _, preset := range strings.Split(presetsStr, ",") {
+			rule, err := NewRedactPreset(strings.TrimSpace(preset), mode)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	// Rule children are processed here, other children are receivers.
+	receiversNode := &xmlNode{name: node.name, attributes: node.attributes, value: node.value, nodePosition: node.nodePosition}
+	for _, childNode := range node.children {
+		if childNode.name != redactRuleID {
+			receiversNode.children = append(receiversNode.children, childNode)
+			continue
+		}
+		err := checkUnexpectedAttribute(childNode, redactRulePatternAttr, redactModeAttr)
+		if err != nil {
+			return nil, err
+		}
+		if childNode.hasChildren() {
+			return nil, errNodeCannotHaveChildren
+		}
+		pattern, ok := childNode.attributes[redactRulePatternAttr]
+		if !ok {
+			return nil, newMissingArgumentError(childNode.name, redactRulePatternAttr)
+		}
+		ruleMode, err := parseRedactMode(childNode, mode)
+		if err != nil {
+			return nil, err
+		}
+		rule, err := NewRedactRule(pattern, ruleMode)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 0 {
+		return nil, errors.New("redact element must have presets or rules")
+	}
+	if hashKey, ok := node.attributes[redactHashKeyAttr]; ok {
+		if len(hashKey) == 0 {
+			return nil, errors.New("redact hash key can not be empty")
+		}
+		for _, rule := range rules {
+			rule.SetHashKey([]byte(hashKey))
+		}
+	}
+	if !receiversNode.hasChildren() {
+		return nil, errNodeMustHaveChildren
+	}
+
+	receivers, err := createInnerReceivers(receiversNode, currentFormat, formats, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedactDispatcher(currentFormat, receivers, rules...)
+}
+
+func parseRedactMode(node *xmlNode, defaultMode RedactMode) (RedactMode, error) {
+	modeStr, ok := node.attributes[redactModeAttr]
+	if !ok {
+		return defaultMode, nil
+	}
+	mode, ok := redactModeFromString(modeStr)
+	if !ok {
+		return 0, fmt.Errorf("invalid redact mode: '%s'", modeStr)
+	}
+	return mode, nil
 }
 
 func createfileWriter(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
-	err := checkUnexpectedAttribute(node, outputFormatID, pathID)
+	if dynamic, err := isDynamicFileNode(node); err != nil || dynamic {
+		if err != nil {
+			return nil, err
+		}
+		return createDynamicFileDispatcher(node, pathID, formatFromParent, formats, cfg, createfileWriter)
+	}
+
+	err := checkUnexpectedAttribute(node, outputFormatID, pathID, reopenIntervalAttr, fileLockAttr,
+		fileSyncAttr, fileSyncLevelAttr, fileSyncIntervalAttr)
 	if err != nil {
 		return nil, err
 	}
@@ -769,14 +1164,128 @@ func createfileWriter(node *xmlNode, formatFromParent *formatter, formats map[st
 		return nil, newMissingArgumentError(node.name, pathID)
 	}
 
+	reopenInterval, err := parseReopenInterval(node)
+	if err != nil {
+		return nil, err
+	}
+
+	locking, err := parseFileLock(node)
+	if err != nil {
+		return nil, err
+	}
+
+	syncPolicy, err := parseSyncPolicy(node)
+	if err != nil {
+		return nil, err
+	}
+
 	fileWriter, err := NewFileWriter(path)
 	if err != nil {
 		return nil, err
 	}
+	fileWriter.SetReopenInterval(reopenInterval)
+	if locking {
+		if err := fileWriter.SetLocking(true); err != nil {
+			return nil, err
+		}
+	}
+	if err := fileWriter.SetSyncPolicy(syncPolicy); err != nil {
+		return nil, err
+	}
+	cfg.applyClock(fileWriter)
 
 	return NewFormattedWriter(fileWriter, currentFormat)
 }
 
+func isDynamicFileNode(node *xmlNode) (bool, error) {
+	dynamicStr, ok := node.attributes[dynamicFileAttr]
+	if !ok {
+		return false, nil
+	}
+	dynamic, err := strconv.ParseBool(dynamicStr)
+	if err != nil {
+		return false, fmt.Errorf("'%s' should be true or false, but was %s", dynamicFileAttr, dynamicStr)
+	}
+	return dynamic, nil
+}
+
+// createDynamicFileDispatcher creates a dispatcher for a file writer node with a templated path.
+// Writers for the produced paths are created by the node constructor, from copies of the node
+// with the path attribute set to the produced path.
+func createDynamicFileDispatcher(node *xmlNode, pathAttr string, formatFromParent *formatter, formats map[string]*formatter,
+	cfg *CfgParseParams, create func(*xmlNode, *formatter, map[string]*formatter, *CfgParseParams) (interface{}, error)) (interface{}, error) {
+
+	pathTemplate, ok := node.attributes[pathAttr]
+	if !ok {
+		return nil, newMissingArgumentError(node.name, pathAttr)
+	}
+
+	maxOpen := dynamicFileDefaultMaxOpen
+	if maxOpenStr, ok := node.attributes[dynamicFileMaxOpenAttr]; ok {
+		var err error
+		maxOpen, err = strconv.Atoi(maxOpenStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	idleTimeout := dynamicFileDefaultIdleTimeout
+	if idleTimeoutStr, ok := node.attributes[dynamicFileIdleTimeoutAttr]; ok {
+		var err error
+		idleTimeout, err = time.ParseDuration(idleTimeoutStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writerNode := &xmlNode{name: node.name, attributes: make(map[string]string), children: node.children, value: node.value,
+		nodePosition: node.nodePosition}
+	for attr, value := range node.attributes {
+		if attr != dynamicFileAttr && attr != dynamicFileMaxOpenAttr && attr != dynamicFileIdleTimeoutAttr {
+			writerNode.attributes[attr] = value
+		}
+	}
+	newWriter := func(path string) (*formattedWriter, error) {
+		pathNode := *writerNode
+		pathNode.attributes = make(map[string]string, len(writerNode.attributes))
+		for attr, value := range writerNode.attributes {
+			pathNode.attributes[attr] = value
+		}
+		pathNode.attributes[pathAttr] = path
+		writer, err := create(&pathNode, formatFromParent, formats, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return writer.(*formattedWriter), nil
+	}
+
+	// Writers are created lazily, so checking the other attributes on the template costs nothing.
+	templateWriter, err := newWriter(pathTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	disp, err := NewDynamicFileDispatcher(templateWriter.Format(), pathTemplate, func(path string) (io.Writer, error) {
+		writer, err := newWriter(path)
+		if err != nil {
+			return nil, err
+		}
+		return writer.Writer(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := disp.SetMaxOpen(maxOpen); err != nil {
+		return nil, err
+	}
+	disp.SetIdleTimeout(idleTimeout)
+	disp.writerInfo = fmt.Sprint(templateWriter.Writer())
+	disp.template = templateWriter.Writer()
+	cfg.applyClock(disp)
+
+	return disp, nil
+}
+
 // Creates new SMTP writer if encountered in the config file.
 func createSMTPWriter(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
 	err := checkUnexpectedAttribute(node, outputFormatID, senderaddressID, senderNameID, hostNameID, hostPortID, userNameID, userPassID, subjectID)
@@ -982,6 +1491,13 @@ func createconnWriter(node *xmlNode, formatFromParent *formatter, formats map[st
 }
 
 func createRollingFileWriter(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
+	if dynamic, err := isDynamicFileNode(node); err != nil || dynamic {
+		if err != nil {
+			return nil, err
+		}
+		return createDynamicFileDispatcher(node, rollingFilePathAttr, formatFromParent, formats, cfg, createRollingFileWriter)
+	}
+
 	if node.hasChildren() {
 		return nil, errNodeCannotHaveChildren
 	}
@@ -1060,56 +1576,38 @@ func createRollingFileWriter(node *xmlNode, formatFromParent *formatter, formats
 		}
 	}
 
-	if rollingType == rollingTypeSize {
-		err := checkUnexpectedAttribute(node, outputFormatID, rollingFileTypeAttr, rollingFilePathAttr,
-			rollingFileMaxSizeAttr, rollingFileMaxRollsAttr, rollingFileArchiveAttr,
-			rollingFileArchivePathAttr, rollingFileArchiveExplodedAttr, rollingFileNameModeAttr)
+	maxRolls := 0
+	if maxRollsStr, ok := node.attributes[rollingFileMaxRollsAttr]; ok {
+		maxRolls, err = strconv.Atoi(maxRollsStr)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		maxSizeStr, ok := node.attributes[rollingFileMaxSizeAttr]
-		if !ok {
-			return nil, newMissingArgumentError(node.name, rollingFileMaxSizeAttr)
-		}
-
-		maxSize, err := strconv.ParseInt(maxSizeStr, 10, 64)
+	var writer io.Writer
+	var rollingWriter *rollingFileWriter
+	switch rollingType {
+	case rollingTypeSize:
+		err := checkUnexpectedAttribute(node, rollingFileAttrs(rollingFileMaxSizeAttr)...)
 		if err != nil {
 			return nil, err
 		}
 
-		maxRolls := 0
-		maxRollsStr, ok := node.attributes[rollingFileMaxRollsAttr]
-		if ok {
-			maxRolls, err = strconv.Atoi(maxRollsStr)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		rollingWriter, err := NewRollingFileWriterSize(path, rArchiveType, rArchivePath, maxSize, maxRolls, nameMode, rArchiveExploded)
+		maxSize, err := parseRollingMaxSize(node)
 		if err != nil {
 			return nil, err
 		}
 
-		return NewFormattedWriter(rollingWriter, currentFormat)
-
-	} else if rollingType == rollingTypeTime {
-		err := checkUnexpectedAttribute(node, outputFormatID, rollingFileTypeAttr, rollingFilePathAttr,
-			rollingFileDataPatternAttr, rollingFileArchiveAttr, rollingFileMaxRollsAttr,
-			rollingFileArchivePathAttr, rollingFileArchiveExplodedAttr, rollingFileNameModeAttr,
-			rollingFileFullNameAttr)
+		sizeWriter, err := NewRollingFileWriterSize(path, rArchiveType, rArchivePath, maxSize, maxRolls, nameMode, rArchiveExploded)
 		if err != nil {
 			return nil, err
 		}
+		writer, rollingWriter = sizeWriter, sizeWriter.rollingFileWriter
 
-		maxRolls := 0
-		maxRollsStr, ok := node.attributes[rollingFileMaxRollsAttr]
-		if ok {
-			maxRolls, err = strconv.Atoi(maxRollsStr)
-			if err != nil {
-				return nil, err
-			}
+	case rollingTypeTime:
+		err := checkUnexpectedAttribute(node, rollingFileAttrs(rollingFileDataPatternAttr, rollingFileFullNameAttr)...)
+		if err != nil {
+			return nil, err
 		}
 
 		fullName := false
@@ -1129,15 +1627,288 @@ func createRollingFileWriter(node *xmlNode, formatFromParent *formatter, formats
 			return nil, newMissingArgumentError(node.name, rollingFileDataPatternAttr)
 		}
 
-		rollingWriter, err := NewRollingFileWriterTime(path, rArchiveType, rArchivePath, maxRolls, dataPattern, nameMode, rArchiveExploded, fullName)
+		timeWriter, err := NewRollingFileWriterTime(path, rArchiveType, rArchivePath, maxRolls, dataPattern, nameMode, rArchiveExploded, fullName)
 		if err != nil {
 			return nil, err
 		}
+		writer, rollingWriter = timeWriter, timeWriter.rollingFileWriter
+
+	case rollingTypeDateSize:
+		err := checkUnexpectedAttribute(node, rollingFileAttrs(rollingFileDataPatternAttr, rollingFileMaxSizeAttr)...)
+		if err != nil {
+			return nil, err
+		}
+
+		maxSize, err := parseRollingMaxSize(node)
+		if err != nil {
+			return nil, err
+		}
+
+		dataPattern, ok := node.attributes[rollingFileDataPatternAttr]
+		if !ok {
+			return nil, newMissingArgumentError(node.name, rollingFileDataPatternAttr)
+		}
+
+		dateSizeWriter, err := NewRollingFileWriterDateSize(path, rArchiveType, rArchivePath, maxSize, maxRolls, dataPattern, nameMode, rArchiveExploded)
+		if err != nil {
+			return nil, err
+		}
+		writer, rollingWriter = dateSizeWriter, dateSizeWriter.rollingFileWriter
+
+	default:
+		return nil, errors.New("incorrect rolling writer type " + rollingTypeStr)
+	}
+
+	if err := applyRollingFileOptions(rollingWriter, node, cfg); err != nil {
+		return nil, err
+	}
+
+	return NewFormattedWriter(writer, currentFormat)
+}
+
+// rollingFileAttrs returns the attributes of rolling file writers of every type along
+// with the given attributes specific to a type.
+func rollingFileAttrs(specific ...string) []string {
+	attrs := []string{outputFormatID, rollingFileTypeAttr, rollingFilePathAttr, rollingFileMaxRollsAttr,
+		rollingFileArchiveAttr, rollingFileArchivePathAttr, rollingFileArchiveExplodedAttr,
+		rollingFileNameModeAttr, rollingFileMaxAgeAttr, rollingFileMaxTotalSizeAttr,
+		rollingFileArchiveAsyncAttr, rollingFileArchiveQueueAttr, rollingFileArchiveLevelAttr,
+		reopenIntervalAttr, rollingFileSymlinkAttr, rollingFileHooksAttr, fileLockAttr,
+		fileSyncAttr, fileSyncLevelAttr, fileSyncIntervalAttr}
+	return append(attrs, specific...)
+}
+
+func parseRollingMaxSize(node *xmlNode) (int64, error) {
+	maxSizeStr, ok := node.attributes[rollingFileMaxSizeAttr]
+	if !ok {
+		return 0, newMissingArgumentError(node.name, rollingFileMaxSizeAttr)
+	}
+	return strconv.ParseInt(maxSizeStr, 10, 64)
+}
+
+// applyRollingFileOptions sets the options shared by rolling file writers of every type.
+func applyRollingFileOptions(rollingWriter *rollingFileWriter, node *xmlNode, cfg *CfgParseParams) error {
+	maxAge, maxTotalSize, err := parseRollingRetention(node)
+	if err != nil {
+		return err
+	}
+
+	archiveQueueSize, err := parseRollingArchiveQueue(node)
+	if err != nil {
+		return err
+	}
+
+	reopenInterval, err := parseReopenInterval(node)
+	if err != nil {
+		return err
+	}
+
+	hooks, err := parseRollHooks(node, cfg)
+	if err != nil {
+		return err
+	}
+
+	locking, err := parseFileLock(node)
+	if err != nil {
+		return err
+	}
+
+	syncPolicy, err := parseSyncPolicy(node)
+	if err != nil {
+		return err
+	}
+
+	archiveLevel := rollingArchiveDefaultLevel
+	if archiveLevelStr, ok := node.attributes[rollingFileArchiveLevelAttr]; ok {
+		archiveLevel, err = strconv.Atoi(archiveLevelStr)
+		if err != nil {
+			return err
+		}
+	}
+
+	rollingWriter.SetRetention(maxAge, maxTotalSize)
+	rollingWriter.SetArchiveAsync(archiveQueueSize)
+	rollingWriter.SetReopenInterval(reopenInterval)
+	if err := rollingWriter.SetSymlink(node.attributes[rollingFileSymlinkAttr]); err != nil {
+		return err
+	}
+	if err := rollingWriter.SetArchiveLevel(archiveLevel); err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		rollingWriter.addRollHook(hook.name, hook.hook)
+	}
+	if locking {
+		if err := rollingWriter.SetLocking(true); err != nil {
+			return err
+		}
+	}
+	if err := rollingWriter.SetSyncPolicy(syncPolicy); err != nil {
+		return err
+	}
+	cfg.applyClock(rollingWriter)
+	return nil
+}
+
+func parseRollHooks(node *xmlNode, cfg *CfgParseParams) ([]rollHookEntry, error) {
+	hooksStr, ok := node.attributes[rollingFileHooksAttr]
+	if !ok {
+		return nil, nil
+	}
+	var hooks []rollHookEntry
+	for _, name := range strings.Split(hooksStr, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var hook RollHook
+		if cfg != nil && cfg.RollHooks != nil {
+			hook = cfg.RollHooks[name]
+		}
+		if hook == nil {
+			hook = registeredRollHooks[name]
+		}
+		if hook == nil {
+			return nil, fmt.Errorf("unknown roll hook: %s", name)
+		}
+		hooks = append(hooks, rollHookEntry{name, hook})
+	}
+	return hooks, nil
+}
+
+func parseRollingRetention(node *xmlNode) (maxAge time.Duration, maxTotalSize int64, err error) {
+	if maxAgeStr, ok := node.attributes[rollingFileMaxAgeAttr]; ok {
+		maxAge, err = parseRetentionDuration(maxAgeStr)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if maxTotalSizeStr, ok := node.attributes[rollingFileMaxTotalSizeAttr]; ok {
+		maxTotalSize, err = strconv.ParseInt(maxTotalSizeStr, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if maxTotalSize <= 0 {
+			return 0, 0, fmt.Errorf("'%s' must be greater than 0", rollingFileMaxTotalSizeAttr)
+		}
+	}
+	return maxAge, maxTotalSize, nil
+}
+
+func parseFileLock(node *xmlNode) (bool, error) {
+	lockStr, ok := node.attributes[fileLockAttr]
+	if !ok {
+		return false, nil
+	}
+	locking, err := strconv.ParseBool(lockStr)
+	if err != nil {
+		return false, fmt.Errorf("'%s' should be true or false, but was %s", fileLockAttr, lockStr)
+	}
+	return locking, nil
+}
+
+func parseSyncPolicy(node *xmlNode) (SyncPolicy, error) {
+	var policy SyncPolicy
+	if modeStr, ok := node.attributes[fileSyncAttr]; ok {
+		mode, found := syncModeFromString(modeStr)
+		if !found {
+			return policy, errors.New("unknown sync mode: " + modeStr)
+		}
+		policy.Mode = mode
+	}
+
+	levelStr, hasLevel := node.attributes[fileSyncLevelAttr]
+	if hasLevel != (policy.Mode == SyncLevel) {
+		return policy, fmt.Errorf("'%s' must be set if and only if '%s' is '%s'", fileSyncLevelAttr, fileSyncAttr, SyncLevel)
+	}
+	if hasLevel {
+		level, found := LogLevelFromString(levelStr)
+		if !found {
+			return policy, errors.New("unknown sync level: " + levelStr)
+		}
+		policy.Level = level
+	}
 
-		return NewFormattedWriter(rollingWriter, currentFormat)
+	intervalStr, hasInterval := node.attributes[fileSyncIntervalAttr]
+	if hasInterval != (policy.Mode == SyncInterval) {
+		return policy, fmt.Errorf("'%s' must be set if and only if '%s' is '%s'", fileSyncIntervalAttr, fileSyncAttr, SyncInterval)
+	}
+	if hasInterval {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return policy, err
+		}
+		policy.Interval = interval
+	}
+	return policy, nil
+}
+
+func parseReopenInterval(node *xmlNode) (time.Duration, error) {
+	intervalStr, ok := node.attributes[reopenIntervalAttr]
+	if !ok {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return 0, err
 	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("'%s' must be greater than 0", reopenIntervalAttr)
+	}
+	return interval, nil
+}
 
-	return nil, errors.New("incorrect rolling writer type " + rollingTypeStr)
+// parseRollingArchiveQueue returns the size of the background archiving queue,
+// 0 if archiving is done inline.
+func parseRollingArchiveQueue(node *xmlNode) (int, error) {
+	asyncStr, ok := node.attributes[rollingFileArchiveAsyncAttr]
+	if !ok {
+		if _, ok := node.attributes[rollingFileArchiveQueueAttr]; ok {
+			return 0, fmt.Errorf("'%s' requires '%s' attribute", rollingFileArchiveQueueAttr, rollingFileArchiveAsyncAttr)
+		}
+		return 0, nil
+	}
+	async, err := strconv.ParseBool(asyncStr)
+	if err != nil {
+		return 0, fmt.Errorf("%s should be true or false, but was %s", rollingFileArchiveAsyncAttr, asyncStr)
+	}
+	if !async {
+		return 0, nil
+	}
+	queueSize := rollingArchiveDefaultQueueSize
+	if queueStr, ok := node.attributes[rollingFileArchiveQueueAttr]; ok {
+		queueSize, err = strconv.Atoi(queueStr)
+		if err != nil {
+			return 0, err
+		}
+		if queueSize <= 0 {
+			return 0, fmt.Errorf("'%s' must be greater than 0", rollingFileArchiveQueueAttr)
+		}
+	}
+	return queueSize, nil
+}
+
+// parseRetentionDuration parses a duration in time.ParseDuration format
+// or in days, like '7d'.
+func parseRetentionDuration(str string) (time.Duration, error) {
+	var duration time.Duration
+	if strings.HasSuffix(str, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(str, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: '%s'", str)
+		}
+		duration = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		duration, err = time.ParseDuration(str)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("'%s' must be greater than 0", rollingFileMaxAgeAttr)
+	}
+	return duration, nil
 }
 
 func createbufferedWriter(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
@@ -1194,10 +1965,44 @@ func createbufferedWriter(node *xmlNode, formatFromParent *formatter, formats ma
 	if err != nil {
 		return nil, err
 	}
+	cfg.applyClock(bufferedWriter)
 
 	return NewFormattedWriter(bufferedWriter, currentFormat)
 }
 
+func createMemoryWriter(node *xmlNode, formatFromParent *formatter, formats map[string]*formatter, cfg *CfgParseParams) (interface{}, error) {
+	err := checkUnexpectedAttribute(node, outputFormatID, memoryNameAttr, memoryCapacityAttr)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.hasChildren() {
+		return nil, errNodeCannotHaveChildren
+	}
+
+	currentFormat, err := getCurrentFormat(node, formatFromParent, formats)
+	if err != nil {
+		return nil, err
+	}
+
+	name, isName := node.attributes[memoryNameAttr]
+	if !isName {
+		return nil, newMissingArgumentError(node.name, memoryNameAttr)
+	}
+
+	capacityStr, isCapacity := node.attributes[memoryCapacityAttr]
+	if !isCapacity {
+		return nil, newMissingArgumentError(node.name, memoryCapacityAttr)
+	}
+
+	capacity, err := strconv.Atoi(capacityStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMemoryWriter(currentFormat, name, capacity)
+}
+
 // Returns an error if node has any attributes not listed in expectedAttrs.
 func checkUnexpectedAttribute(node *xmlNode, expectedAttrs ...string) error {
 	for attr := range node.attributes {
@@ -26,10 +26,12 @@ package seelog
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 type customTestReceiverOutput struct {
@@ -1073,6 +1075,515 @@ func getParserTests() []parserTest {
 		testExpected.RootDispatcher = testHeadSplitter
 		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
 
+		testName = "Memory writer"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<memory name="recent" capacity="50" formatid="testFormat"/>
+			</outputs>
+			<formats>
+				<format id="testFormat" format="%Level %Msg" />
+			</formats>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testFormat, _ = NewFormatter("%Level %Msg")
+		testMemoryWriter, _ := NewMemoryWriter(testFormat, "recent", 50)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testMemoryWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Memory writer without capacity"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<memory name="recent"/>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Filter dispatcher with rules"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<filter regexp="^audit:" funcpattern="*myapp/auth.*" context="user=adm*">
+					<file path="` + testLogFileName + `"/>
+				</filter>
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testfileWriter, _ = NewFileWriter(testLogFileName)
+		testRegexpRule, _ := NewMessageRegexpRule("^audit:")
+		testFuncRule, _ := NewFuncPatternRule("*myapp/auth.*")
+		testContextRule, _ := NewContextFieldRule("user", "adm*")
+		testFilter, _ = NewRuleFilterDispatcher(DefaultFormatter, []interface{}{testfileWriter}, FilterInclude, nil,
+			testRegexpRule, testFuncRule, testContextRule)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testFilter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Filter dispatcher in exclude mode"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<filter levels="trace" contains="heartbeat" mode="exclude">
+					<file path="` + testLogFileName + `"/>
+				</filter>
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testfileWriter, _ = NewFileWriter(testLogFileName)
+		testFilter, _ = NewRuleFilterDispatcher(DefaultFormatter, []interface{}{testfileWriter}, FilterExclude,
+			[]LogLevel{TraceLvl}, NewMessageContainsRule("heartbeat"))
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testFilter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Filter dispatcher with invalid mode"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<filter contains="x" mode="skip">
+					<console/>
+				</filter>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Filter dispatcher with invalid context rule"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<filter context="user">
+					<console/>
+				</filter>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Redact dispatcher"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<redact presets="bearer, password" replace="hash" hashkey="key">
+					<rule pattern="ssn=\d{3}-\d{2}-\d{4}" replace="mask"/>
+					<file path="` + testLogFileName + `"/>
+				</redact>
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testfileWriter, _ = NewFileWriter(testLogFileName)
+		testBearerRule, _ := NewRedactPreset("bearer", RedactHash)
+		testPasswordRule, _ := NewRedactPreset("password", RedactHash)
+		testSSNRule, _ := NewRedactRule(`ssn=\d{3}-\d{2}-\d{4}`, RedactMask)
+		for _, rule := range []*redactRule{testBearerRule, testPasswordRule, testSSNRule} {
+			rule.SetHashKey([]byte("key"))
+		}
+		testRedact, _ := NewRedactDispatcher(DefaultFormatter, []interface{}{testfileWriter},
+			testBearerRule, testPasswordRule, testSSNRule)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testRedact})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Redact dispatcher without rules"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<redact>
+					<console/>
+				</redact>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Redact dispatcher with unknown preset"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<redact presets="phone">
+					<console/>
+				</redact>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Rolling file writer by date and size"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="datesize" filename="` + testLogFileName + `" datepattern="2006-01-02" maxsize="100" maxrolls="5" />
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testDateSizeWriter, _ := NewRollingFileWriterDateSize(testLogFileName, rollingArchiveNone, "", 100, 5, "2006-01-02", rollingNameModePostfix, false)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testDateSizeWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Rolling file writer by date and size without maxsize"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="datesize" filename="` + testLogFileName + `" datepattern="2006-01-02" />
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Rolling file writer with retention"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" maxage="7d" maxtotalsize="1000" />
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testRetentionWriter, _ := NewRollingFileWriterSize(testLogFileName, rollingArchiveNone, "", 100, 0, rollingNameModePostfix, false)
+		testRetentionWriter.SetRetention(7*24*time.Hour, 1000)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testRetentionWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Rolling file writer with invalid maxage"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="date" filename="` + testLogFileName + `" datepattern="2006-01-02" maxage="week" />
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Rolling file writer with background archiving"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" maxrolls="5" archivetype="zip" archiveasync="true" archivequeue="4" />
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testAsyncWriter, _ := NewRollingFileWriterSize(testLogFileName, rollingArchiveZip, "log.zip", 100, 5, rollingNameModePostfix, false)
+		testAsyncWriter.SetArchiveAsync(4)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testAsyncWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Rolling file writer with archive queue but without background archiving"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" archivetype="zip" archivequeue="4" />
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Rolling file writer with archive level"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" maxrolls="5" archivetype="gzip" archivelevel="9" />
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testLevelWriter, _ := NewRollingFileWriterSize(testLogFileName, rollingArchiveGzip, "log.tar.gz", 100, 5, rollingNameModePostfix, false)
+		testLevelWriter.SetArchiveLevel(9)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testLevelWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Rolling file writer with invalid archive level"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" archivetype="zip" archivelevel="42" />
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "File writers with reopen interval"
+		testLogFileName = getTestFileName(testName, "")
+		testLogFileName2 = getTestFileName(testName, "2")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<file path="` + testLogFileName + `" reopeninterval="30s"/>
+				<rollingfile type="size" filename="` + testLogFileName2 + `" maxsize="100" reopeninterval="1m" />
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testfileWriter, _ = NewFileWriter(testLogFileName)
+		testfileWriter.SetReopenInterval(30 * time.Second)
+		testReopenWriter, _ := NewRollingFileWriterSize(testLogFileName2, rollingArchiveNone, "", 100, 0, rollingNameModePostfix, false)
+		testReopenWriter.SetReopenInterval(time.Minute)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testfileWriter, testReopenWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "File writer with invalid reopen interval"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<file path="` + testLogFileName + `" reopeninterval="often"/>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Rolling file writer with symlink"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="date" filename="` + testLogFileName + `" datepattern="2006-01-02" fullname="true" symlink="` + testLogFileName + `" />
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testSymlinkWriter, _ := NewRollingFileWriterTime(testLogFileName, rollingArchiveNone, "", 0, "2006-01-02", rollingNameModePostfix, false, true)
+		testSymlinkWriter.SetSymlink(testLogFileName)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testSymlinkWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Rolling file writer with symlink replacing the log file"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" symlink="` + testLogFileName + `" />
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Rolling file writer with roll hooks"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" hooks="upload, notify" />
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testHookWriter, _ := NewRollingFileWriterSize(testLogFileName, rollingArchiveNone, "", 100, 0, rollingNameModePostfix, false)
+		testHookWriter.addRollHook("upload", nil)
+		testHookWriter.addRollHook("notify", nil)
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testHookWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		hookCfg := CfgParseParams{
+			RollHooks: map[string]RollHook{
+				"upload": func(RollEvent) {},
+				"notify": func(RollEvent) {},
+			},
+		}
+		testExpected.Params = &hookCfg
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, &hookCfg})
+
+		testName = "Rolling file writer with unknown roll hook"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" hooks="unknown" />
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Dynamic file writer"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<file dynamic="true" maxopen="8" idletimeout="1m" path="logs/%Ctx(Tenant)/%Date(2006-01-02).log"/>
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testDynamicTemplateWriter, _ := NewFileWriter("logs/%Ctx(Tenant)/%Date(2006-01-02).log")
+		testDynamicDispatcher, _ := NewDynamicFileDispatcher(DefaultFormatter, "logs/%Ctx(Tenant)/%Date(2006-01-02).log",
+			func(path string) (io.Writer, error) { return NewFileWriter(path) })
+		testDynamicDispatcher.SetMaxOpen(8)
+		testDynamicDispatcher.SetIdleTimeout(time.Minute)
+		testDynamicDispatcher.writerInfo = testDynamicTemplateWriter.String()
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testDynamicDispatcher})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "Dynamic file writer with incorrect template"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<file dynamic="true" path="logs/%Unknown/app.log"/>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Dynamic rolling file writer with incorrect attribute"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile dynamic="true" type="size" filename="logs/%Ctx(Tenant)/app.log" maxsize="100" datepattern="2006"/>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		if fileLockingSupported {
+			testName = "Rolling file writer with locking"
+			testLogFileName = getTestFileName(testName, "")
+			testConfig = `
+			<seelog type="sync">
+				<outputs>
+					<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" lock="true"/>
+				</outputs>
+			</seelog>`
+			testExpected = new(configForParsing)
+			testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+			testExpected.Exceptions = nil
+			testLockingWriter, _ := NewRollingFileWriterSize(testLogFileName, rollingArchiveNone, "", 100, 0, rollingNameModePostfix, false)
+			testLockingWriter.SetLocking(true)
+			testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testLockingWriter})
+			testExpected.LogType = syncloggerTypeFromString
+			testExpected.RootDispatcher = testHeadSplitter
+			parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+		}
+
+		testName = "File writer with incorrect lock"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<file path="log.log" lock="maybe"/>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "Rolling file writer with sync policy"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<rollingfile type="size" filename="` + testLogFileName + `" maxsize="100" sync="level" synclevel="error"/>
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testSyncWriter, _ := NewRollingFileWriterSize(testLogFileName, rollingArchiveNone, "", 100, 0, rollingNameModePostfix, false)
+		testSyncWriter.SetSyncPolicy(SyncPolicy{Mode: SyncLevel, Level: ErrorLvl})
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testSyncWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "File writer with sync interval"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<file path="log.log" sync="interval" syncinterval="5s"/>
+			</outputs>
+		</seelog>`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testExpected.Exceptions = nil
+		testSyncFileWriter, _ := NewFileWriter("log.log")
+		testSyncFileWriter.SetSyncPolicy(SyncPolicy{Mode: SyncInterval, Interval: 5 * time.Second})
+		testHeadSplitter, _ = NewSplitDispatcher(DefaultFormatter, []interface{}{testSyncFileWriter})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "File writer with sync interval without interval"
+		testConfig = `
+		<seelog type="sync">
+			<outputs>
+				<file path="log.log" sync="interval"/>
+			</outputs>
+		</seelog>`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
+		testName = "JSON config"
+		testLogFileName = getTestFileName(testName, "")
+		testConfig = `
+		{
+			"seelog": {
+				"type": "sync",
+				"minlevel": "info",
+				"outputs": {
+					"formatid": "msg",
+					"filter": {
+						"levels": "error",
+						"file": {"path": "` + testLogFileName + `"}
+					},
+					"rollingfile": [
+						{"type": "size", "filename": "` + testLogFileName + `.1", "maxsize": 100, "maxrolls": 5}
+					]
+				},
+				"formats": {
+					"format": [{"id": "msg", "format": "%Msg"}]
+				},
+				"exceptions": {
+					"exception": [{"funcpattern": "*main.test*", "minlevel": "trace"}]
+				}
+			}
+		}`
+		testExpected = new(configForParsing)
+		testExpected.Constraints, _ = NewMinMaxConstraints(InfoLvl, CriticalLvl)
+		testExceptionConstraints, _ := NewMinMaxConstraints(TraceLvl, CriticalLvl)
+		testJSONException, _ := NewLogLevelException("*main.test*", "*", testExceptionConstraints)
+		testExpected.Exceptions = []*LogLevelException{testJSONException}
+		testJSONFormat, _ := NewFormatter("%Msg")
+		testfileWriter, _ = NewFileWriter(testLogFileName)
+		testJSONFilter, _ := NewFilterDispatcher(testJSONFormat, []interface{}{testfileWriter}, ErrorLvl)
+		testJSONRollingWriter, _ := NewRollingFileWriterSize(testLogFileName+".1", rollingArchiveNone, "", 100, 5, rollingNameModePostfix, false)
+		testJSONRollingFormatted, _ := NewFormattedWriter(testJSONRollingWriter, testJSONFormat)
+		testHeadSplitter, _ = NewSplitDispatcher(testJSONFormat, []interface{}{testJSONFilter, testJSONRollingFormatted})
+		testExpected.LogType = syncloggerTypeFromString
+		testExpected.RootDispatcher = testHeadSplitter
+		parserTests = append(parserTests, parserTest{testName, testConfig, testExpected, false, nil})
+
+		testName = "JSON config with unknown element"
+		testConfig = `{"seelog": {"outputs": {"nofile": {"path": "log.log"}}}}`
+		parserTests = append(parserTests, parserTest{testName, testConfig, nil, true, nil})
+
 	}
 
 	return parserTests
@@ -1141,6 +1652,17 @@ func parseTest(test parserTest, t *testing.T) {
 	}
 }
 
+func TestJSONConfigErrorLocation(t *testing.T) {
+	config := `{"seelog": {"outputs": {"filter": {"levels": "error", "file": [{"path": "a.log"}, {"path": "b.log", "unknown": "1"}]}}}}`
+	_, err := configFromReader(strings.NewReader(config))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.HasPrefix(err.Error(), "$.seelog.outputs.filter.file[1]: ") {
+		t.Errorf("expected the JSON path of the file in the error, got: %s", err)
+	}
+}
+
 func TestParser(t *testing.T) {
 	defer cleanupAfterCfgTest(t)
 
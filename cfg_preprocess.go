@@ -0,0 +1,215 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	includeID       = "include"
+	includeFileAttr = "file"
+)
+
+// preprocessConfig expands variable references in the attributes of a config tree and
+// replaces '<include>' elements with the contents of the included files. Relative include
+// paths are resolved against baseDir, which is empty for configs that are not files.
+func preprocessConfig(config *xmlNode, baseDir string, cfg *CfgParseParams) error {
+	return preprocessConfigFile(config, baseDir, cfg, make(map[string]bool))
+}
+
+func preprocessConfigFile(config *xmlNode, baseDir string, cfg *CfgParseParams, including map[string]bool) error {
+	if err := expandNodeVariables(config, cfg); err != nil {
+		return err
+	}
+
+	children := config.children
+	config.children = make([]*xmlNode, 0, len(children))
+	for _, child := range children {
+		if child.name != includeID {
+			config.add(child)
+			continue
+		}
+		included, err := loadIncludedConfig(child, baseDir, cfg, including)
+		if err != nil {
+			return locateError(child, err)
+		}
+		if err := mergeIncludedConfig(config, included); err != nil {
+			return locateError(child, err)
+		}
+	}
+	return nil
+}
+
+// loadIncludedConfig reads and preprocesses the file of an '<include>' element.
+func loadIncludedConfig(node *xmlNode, baseDir string, cfg *CfgParseParams, including map[string]bool) (*xmlNode, error) {
+	if err := checkUnexpectedAttribute(node, includeFileAttr); err != nil {
+		return nil, err
+	}
+	if node.hasChildren() {
+		return nil, errNodeCannotHaveChildren
+	}
+	path, ok := node.attributes[includeFileAttr]
+	if !ok {
+		return nil, newMissingArgumentError(node.name, includeFileAttr)
+	}
+	if !filepath.IsAbs(path) && len(baseDir) != 0 {
+		path = filepath.Join(baseDir, path)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if including[absPath] {
+		return nil, fmt.Errorf("config file '%s' includes itself", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	included, err := unmarshalConfigFile(file, path)
+	if err != nil {
+		return nil, fmt.Errorf("included config '%s': %v", path, err)
+	}
+	setNodeFile(included, path)
+	if included.name != seelogConfigID {
+		return nil, fmt.Errorf("root tag of included config '%s' must be '%s'", path, seelogConfigID)
+	}
+	if len(included.attributes) != 0 {
+		return nil, fmt.Errorf("root tag of included config '%s' cannot have attributes", path)
+	}
+
+	including[absPath] = true
+	defer delete(including, absPath)
+	if err := preprocessConfigFile(included, filepath.Dir(path), cfg, including); err != nil {
+		return nil, fmt.Errorf("included config '%s': %v", path, err)
+	}
+	return included, nil
+}
+
+// mergeIncludedConfig appends the formats, outputs and exceptions of an included config to
+// the corresponding sections of the config, adding the sections if the config has none.
+func mergeIncludedConfig(config, included *xmlNode) error {
+	for _, section := range included.children {
+		switch section.name {
+		case formatsID, outputsID, exceptionsID:
+		default:
+			return newUnexpectedChildElementError(section.name)
+		}
+
+		var target *xmlNode
+		for _, child := range config.children {
+			if child.name == section.name {
+				target = child
+				break
+			}
+		}
+		if target == nil {
+			config.add(section)
+			continue
+		}
+
+		if len(section.attributes) == 0 {
+			target.children = append(target.children, section.children...)
+			continue
+		}
+		if section.name != outputsID {
+			return fmt.Errorf("included '%s' section cannot have attributes", section.name)
+		}
+		// Included outputs keep their own format in a splitter.
+		splitter := newNode()
+		splitter.name = splitterDispatcherID
+		splitter.attributes = section.attributes
+		splitter.children = section.children
+		splitter.nodePosition = section.nodePosition
+		target.add(splitter)
+	}
+	return nil
+}
+
+// expandNodeVariables replaces variable references in attribute values of the node and its children.
+func expandNodeVariables(node *xmlNode, cfg *CfgParseParams) error {
+	for attr, value := range node.attributes {
+		expanded, err := expandVariables(value, cfg.lookupVariable)
+		if err != nil {
+			return locateError(node, fmt.Errorf("attribute '%s' of '%s': %v", attr, node.name, err))
+		}
+		node.attributes[attr] = expanded
+	}
+	for _, child := range node.children {
+		if err := expandNodeVariables(child, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandVariables replaces '${NAME}' with the value of the variable and '${NAME:-default}'
+// with the value of the variable, or the default if the variable is unset or empty.
+// '$${' stands for a literal '${'. Referencing an unset variable without a default is an error.
+func expandVariables(str string, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(str, "${") {
+		return str, nil
+	}
+	var result []byte
+	for i := 0; i < len(str); i++ {
+		if strings.HasPrefix(str[i:], "$${") {
+			result = append(result, "${"...)
+			i += 2
+			continue
+		}
+		if !strings.HasPrefix(str[i:], "${") {
+			result = append(result, str[i])
+			continue
+		}
+		end := strings.IndexByte(str[i:], '}')
+		if end == -1 {
+			return "", errors.New("unterminated variable reference: " + str[i:])
+		}
+		reference := str[i+2 : i+end]
+		name, defaultValue, hasDefault := reference, "", false
+		if delim := strings.Index(reference, ":-"); delim != -1 {
+			name, defaultValue, hasDefault = reference[:delim], reference[delim+2:], true
+		}
+		if len(name) == 0 {
+			return "", errors.New("empty variable name")
+		}
+		value, ok := lookup(name)
+		if !ok || (hasDefault && len(value) == 0) {
+			if !hasDefault {
+				return "", fmt.Errorf("variable '%s' is not set", name)
+			}
+			value = defaultValue
+		}
+		result = append(result, value...)
+		i += end
+	}
+	return string(result), nil
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]string{"DIR": "/var/log", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+	tests := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{"plain $text", "plain $text", false},
+		{"${DIR}/app.log", "/var/log/app.log", false},
+		{"${DIR}${DIR}", "/var/log/var/log", false},
+		{"${LEVEL:-info}", "info", false},
+		{"${EMPTY:-default}", "default", false},
+		{"${EMPTY}", "", false},
+		{"${DIR:-/tmp}", "/var/log", false},
+		{"$${DIR}", "${DIR}", false},
+		{"${LEVEL}", "", true},
+		{"${DIR", "", true},
+		{"${}", "", true},
+	}
+	for _, test := range tests {
+		result, err := expandVariables(test.input, lookup)
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error %t, got %v", test.input, test.err, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestConfigInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"seelog.xml": `
+<seelog type="sync" minlevel="${LEVEL:-info}">
+	<outputs formatid="msg">
+		<file path="${LOGDIR}/main.log"/>
+	</outputs>
+	<include file="common/outputs.xml"/>
+</seelog>`,
+		"common/outputs.xml": `
+<seelog>
+	<formats>
+		<format id="msg" format="%Msg"/>
+	</formats>
+	<outputs formatid="msg">
+		<file path="${LOGDIR}/common.log"/>
+	</outputs>
+	<include file="exceptions.json"/>
+</seelog>`,
+		"common/exceptions.json": `{"seelog": {"exceptions": {"exception": {"filepattern": "*test*", "minlevel": "trace"}}}}`,
+		"loop.xml":               `<seelog><include file="common/loop.xml"/></seelog>`,
+		"common/loop.xml":        `<seelog><include file="../loop.xml"/></seelog>`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), defaultDirectoryPermissions); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), defaultFilePermissions); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	params := &CfgParseParams{Variables: map[string]string{"LOGDIR": dir}}
+	conf, err := configFromFile(filepath.Join(dir, "seelog.xml"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conf.RootDispatcher.Close()
+
+	str := conf.RootDispatcher.(*splitDispatcher).String()
+	for _, expected := range []string{
+		"File writer: " + filepath.Join(dir, "main.log"),
+		"File writer: " + filepath.Join(dir, "common.log"),
+	} {
+		if !strings.Contains(str, expected) {
+			t.Errorf("expected %q in outputs:\n%s", expected, str)
+		}
+	}
+	if len(conf.Exceptions) != 1 || conf.Exceptions[0].FilePattern() != "*test*" {
+		t.Errorf("expected the included exception, got %v", conf.Exceptions)
+	}
+	if conf.Constraints.IsAllowed(DebugLvl) || !conf.Constraints.IsAllowed(InfoLvl) {
+		t.Errorf("expected the default min level from the config: %v", conf.Constraints)
+	}
+
+	if _, err := configFromFile(filepath.Join(dir, "loop.xml"), params); err == nil {
+		t.Error("expected an error for an include loop")
+	}
+	if _, err := configFromFile(filepath.Join(dir, "seelog.xml"), nil); err == nil {
+		t.Error("expected an error for the unset variable")
+	}
+}
+
+func TestCfgParseParamsLookupVariable(t *testing.T) {
+	os.Setenv("SEELOG_TEST_VAR", "env")
+	defer os.Unsetenv("SEELOG_TEST_VAR")
+
+	cfg := &CfgParseParams{
+		Variables: map[string]string{"A": "map"},
+		LookupVariable: func(name string) (string, bool) {
+			if name == "B" {
+				return "func", true
+			}
+			return "", false
+		},
+	}
+	if _, ok := cfg.lookupVariable("SEELOG_TEST_VAR"); ok {
+		t.Error("environment variables must not be used without EnvVariables")
+	}
+	cfg.EnvVariables = true
+	for name, expected := range map[string]string{"A": "map", "B": "func", "SEELOG_TEST_VAR": "env"} {
+		if value, _ := cfg.lookupVariable(name); value != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, value)
+		}
+	}
+	if _, ok := cfg.lookupVariable("SEELOG_TEST_UNSET"); ok {
+		t.Error("expected an unset variable")
+	}
+}
+
+func TestConfigLiteralVariableReference(t *testing.T) {
+	os.Setenv("SEELOG_TEST_VAR", "env")
+	defer os.Unsetenv("SEELOG_TEST_VAR")
+
+	config := `
+<seelog type="sync">
+	<outputs formatid="msg">
+		<memory name="literal" capacity="1"/>
+	</outputs>
+	<formats>
+		<format id="msg" format="$${SEELOG_TEST_VAR} %Msg"/>
+	</formats>
+</seelog>`
+	conf, err := configFromReaderWithConfig(strings.NewReader(config), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conf.RootDispatcher.Close()
+	format := conf.RootDispatcher.(*splitDispatcher).formatter.Format("message", InfoLvl, &inspectContext{})
+	if format != "${SEELOG_TEST_VAR} message" {
+		t.Errorf("expected a literal variable reference, got %q", format)
+	}
+
+	if _, err := configFromReaderWithConfig(strings.NewReader(`<seelog minlevel="${SEELOG_TEST_VAR}"/>`), nil); err == nil {
+		t.Error("expected an error for a variable only set in the environment")
+	}
+}
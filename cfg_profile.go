@@ -0,0 +1,219 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ProfileEnvVariable is the environment variable selecting a config profile when
+// CfgParseParams.Profile is empty.
+const ProfileEnvVariable = "SEELOG_PROFILE"
+
+const (
+	profileID       = "profile"
+	profileNameAttr = "name"
+)
+
+// Root attributes replaced as a group when a profile sets any of them.
+var (
+	constraintsAttrs = []string{minLevelID, maxLevelID, levelsID}
+	loggerTypeAttrs  = []string{
+		loggerTypeFromStringAttr,
+		asyncLoggerIntervalAttr,
+		adaptLoggerMinIntervalAttr,
+		adaptLoggerMaxIntervalAttr,
+		adaptLoggerCriticalMsgCountAttr,
+	}
+)
+
+// selectedProfile returns the name of the profile selected by the params or by the environment.
+func (cfg *CfgParseParams) selectedProfile() string {
+	if cfg != nil && len(cfg.Profile) != 0 {
+		return cfg.Profile
+	}
+	return os.Getenv(ProfileEnvVariable)
+}
+
+// resolveProfile applies the selected '<profile>' element of the config on top of the base
+// config and returns the result, which has no profile elements left:
+//   - level attributes of the profile replace all the level attributes of the root element,
+//     the logger type attributes of the profile replace all the logger type attributes;
+//   - 'outputs' and 'exceptions' of the profile replace the base ones;
+//   - formats of the profile are added to the base ones, replacing base formats with the same id.
+//
+// A config without profiles is returned as is, whatever profile is selected. Otherwise the
+// selected profile must be declared. If no profile is selected, the base config is used.
+// When validating, errors are collected and invalid profiles are skipped.
+func resolveProfile(config *xmlNode, cfg *CfgParseParams) (*xmlNode, error) {
+	var profiles []*xmlNode
+	for _, child := range config.children {
+		if child.name == profileID {
+			profiles = append(profiles, child)
+		}
+	}
+	if len(profiles) == 0 {
+		return config, nil
+	}
+
+	var selected *xmlNode
+	name := cfg.selectedProfile()
+	names := make(map[string]bool)
+	declared := false
+	for _, profile := range profiles {
+		profileName := profile.attributes[profileNameAttr]
+		err := checkProfile(profile)
+		if err == nil && names[profileName] {
+			err = fmt.Errorf("profile '%s' is declared more than once", profileName)
+		}
+		names[profileName] = true
+		declared = declared || profileName == name
+		if err != nil {
+			if !cfg.collectError(profile, err) {
+				return nil, locateError(profile, err)
+			}
+			continue
+		}
+		if profileName == name {
+			selected = profile
+		}
+	}
+	if !declared && len(name) != 0 {
+		err := fmt.Errorf("profile '%s' is not declared in the config", name)
+		if !cfg.collectError(config, err) {
+			return nil, locateError(config, err)
+		}
+	}
+
+	resolved := &xmlNode{
+		name:         config.name,
+		attributes:   make(map[string]string, len(config.attributes)),
+		value:        config.value,
+		nodePosition: config.nodePosition,
+	}
+	for attr, value := range config.attributes {
+		resolved.attributes[attr] = value
+	}
+	for _, child := range config.children {
+		if child.name != profileID {
+			resolved.add(child)
+		}
+	}
+	if selected == nil {
+		return resolved, nil
+	}
+
+	overrideAttributes(resolved, selected, constraintsAttrs)
+	overrideAttributes(resolved, selected, loggerTypeAttrs)
+	for _, section := range selected.children {
+		if section.name == formatsID {
+			mergeProfileFormats(resolved, section)
+		} else {
+			replaceSection(resolved, section)
+		}
+	}
+	return resolved, nil
+}
+
+func checkProfile(profile *xmlNode) error {
+	attrs := append([]string{profileNameAttr}, constraintsAttrs...)
+	attrs = append(attrs, loggerTypeAttrs...)
+	if err := checkUnexpectedAttribute(profile, attrs...); err != nil {
+		return err
+	}
+	if err := checkExpectedElements(profile, optionalElement(outputsID), optionalElement(formatsID), optionalElement(exceptionsID)); err != nil {
+		return err
+	}
+	if len(profile.attributes[profileNameAttr]) == 0 {
+		return errors.New("profile must have a non-empty '" + profileNameAttr + "' attribute")
+	}
+	return nil
+}
+
+// overrideAttributes replaces the group of attributes of the node with the profile ones,
+// if the profile sets any of them.
+func overrideAttributes(node, profile *xmlNode, group []string) {
+	set := false
+	for _, attr := range group {
+		if _, ok := profile.attributes[attr]; ok {
+			set = true
+		}
+	}
+	if !set {
+		return
+	}
+	for _, attr := range group {
+		delete(node.attributes, attr)
+		if value, ok := profile.attributes[attr]; ok {
+			node.attributes[attr] = value
+		}
+	}
+}
+
+// replaceSection replaces the child of the node having the name of the section.
+func replaceSection(node, section *xmlNode) {
+	for i, child := range node.children {
+		if child.name == section.name {
+			node.children[i] = section
+			return
+		}
+	}
+	node.add(section)
+}
+
+// mergeProfileFormats adds the formats of a profile to the formats of the node.
+func mergeProfileFormats(node, section *xmlNode) {
+	var base *xmlNode
+	for _, child := range node.children {
+		if child.name == formatsID {
+			base = child
+			break
+		}
+	}
+	if base == nil {
+		node.add(section)
+		return
+	}
+
+	merged := &xmlNode{
+		name:         base.name,
+		attributes:   base.attributes,
+		nodePosition: base.nodePosition,
+	}
+	overridden := make(map[string]bool)
+	for _, format := range section.children {
+		overridden[format.attributes[formatKeyAttrID]] = true
+	}
+	for _, format := range base.children {
+		if !overridden[format.attributes[formatKeyAttrID]] {
+			merged.add(format)
+		}
+	}
+	for _, format := range section.children {
+		merged.add(format)
+	}
+	replaceSection(node, merged)
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const profilesTestConfig = `<seelog minlevel="info">
+	<outputs formatid="main">
+		<console/>
+	</outputs>
+	<formats>
+		<format id="main" format="%Msg%n"/>
+		<format id="short" format="%l %Msg%n"/>
+	</formats>
+	<profile name="dev" minlevel="trace">
+		<outputs formatid="short">
+			<console/>
+		</outputs>
+	</profile>
+	<profile name="prod" type="asynctimer" asyncinterval="1000" levels="warn,error">
+		<exceptions>
+			<exception funcpattern="main.*" minlevel="error"/>
+		</exceptions>
+		<formats>
+			<format id="main" format="%Date %Msg%n"/>
+		</formats>
+	</profile>
+</seelog>`
+
+func TestConfigProfiles(t *testing.T) {
+	tests := []struct {
+		profile  string
+		expected string
+	}{
+		{"", `<seelog minlevel="info">
+	<formats>
+		<format format="%Msg%n" id="main"/>
+	</formats>
+	<outputs formatid="main">
+		<console/>
+	</outputs>
+</seelog>`},
+		{"dev", `<seelog>
+	<formats>
+		<format format="%l %Msg%n" id="short"/>
+	</formats>
+	<outputs formatid="short">
+		<console/>
+	</outputs>
+</seelog>`},
+		{"prod", `<seelog asyncinterval="1000" levels="warn,error" type="asynctimer">
+	<exceptions>
+		<exception funcpattern="main.*" minlevel="error"/>
+	</exceptions>
+	<formats>
+		<format format="%Date %Msg%n" id="main"/>
+	</formats>
+	<outputs formatid="main">
+		<console/>
+	</outputs>
+</seelog>`},
+	}
+	for _, test := range tests {
+		config, err := configFromReaderWithConfig(strings.NewReader(profilesTestConfig), &CfgParseParams{Profile: test.profile})
+		if err != nil {
+			t.Errorf("profile '%s': %s", test.profile, err)
+			continue
+		}
+		node, err := marshalConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := strings.TrimSpace(string(node.marshalIndent("\t"))); result != test.expected {
+			t.Errorf("profile '%s': expected\n%s\ngot\n%s", test.profile, test.expected, result)
+		}
+	}
+}
+
+func TestConfigProfileFromEnvironment(t *testing.T) {
+	defer os.Unsetenv(ProfileEnvVariable)
+	os.Setenv(ProfileEnvVariable, "dev")
+
+	config, err := configFromReaderWithConfig(strings.NewReader(profilesTestConfig), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Constraints.IsAllowed(TraceLvl) {
+		t.Error("expected the 'dev' profile to be applied")
+	}
+
+	config, err = configFromReaderWithConfig(strings.NewReader(profilesTestConfig), &CfgParseParams{Profile: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.LogType != asyncTimerloggerTypeFromString {
+		t.Error("expected the profile of the params to take precedence over the environment")
+	}
+
+	// Configs without profiles ignore the selected profile.
+	if _, err := configFromReaderWithConfig(strings.NewReader(`<seelog/>`), nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigProfileErrors(t *testing.T) {
+	tests := []struct {
+		config  string
+		profile string
+		err     string
+	}{
+		{profilesTestConfig, "staging", "/seelog: profile 'staging' is not declared in the config"},
+		{`<seelog><profile name="dev"/><profile name="dev"/></seelog>`, "", "/seelog/profile[2]: profile 'dev' is declared more than once"},
+		{`<seelog><profile minlevel="info"/></seelog>`, "", "/seelog/profile: profile must have a non-empty 'name' attribute"},
+		{`<seelog><profile name="dev"><custom name="x"/></profile></seelog>`, "dev", "/seelog/profile: "},
+		{`<seelog><profile name="dev" formatid="x"/></seelog>`, "dev", "/seelog/profile: "},
+	}
+	for _, test := range tests {
+		_, err := configFromReaderWithConfig(strings.NewReader(test.config), &CfgParseParams{Profile: test.profile})
+		if err == nil {
+			t.Errorf("%s: expected an error", test.config)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error containing %q, got %q", test.config, test.err, err)
+		}
+	}
+}
+
+func TestValidateConfigProfileErrors(t *testing.T) {
+	config := `<seelog>
+	<profile name="dev"/>
+	<profile name="dev"/>
+	<profile name="prod" unknown="1"/>
+	<outputs>
+		<console unknown="1"/>
+	</outputs>
+</seelog>`
+
+	err := ValidateConfigAsString(config, &CfgParseParams{Profile: "staging"})
+	errs, ok := err.(ConfigErrors)
+	if !ok {
+		t.Fatalf("expected ConfigErrors, got %T: %v", err, err)
+	}
+	expected := []struct {
+		path string
+		line int
+	}{
+		{"/seelog/profile[2]", 3},
+		{"/seelog/profile[3]", 4},
+		{"/seelog", 1},
+		{"/seelog/outputs/console", 6},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d:\n%v", len(expected), len(errs), errs)
+	}
+	for i, test := range expected {
+		if errs[i].Path != test.path || errs[i].Line != test.line {
+			t.Errorf("error %d: expected %s at line %d, got: %v", i, test.path, test.line, errs[i])
+		}
+	}
+}
@@ -0,0 +1,709 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configSecretMask replaces passwords and other secrets in serialized configs.
+const configSecretMask = "********"
+
+// secretNamePattern matches the names of custom receiver data attributes, of the attributes and
+// elements of custom and registered elements and of mail headers whose values are masked.
+var secretNamePattern = regexp.MustCompile(`(?i)pass|secret|token|credential|authori[sz]ation|\bauth\b|cookie|apikey|api-key|api_key`)
+
+// Attributes of the name/value pair elements of custom elements, like '<param name="token" value="..."/>'.
+const (
+	secretPairNameAttr  = "name"
+	secretPairValueAttr = "value"
+)
+
+// LoggerConfigAsBytes returns the configuration of a logger created by this package as a seelog
+// XML config: the logger type, constraints, exceptions, formats and the outputs tree, as they are
+// at the moment of the call. Passwords and other secrets are masked.
+//
+// A logger created from the returned config behaves like the original, except for the order of
+// receivers (writers are listed before nested dispatchers) and for the masked secrets. Formats
+// keep the ids they were declared with; formats created in code get generated ids.
+func LoggerConfigAsBytes(logger LoggerInterface) ([]byte, error) {
+	node, err := marshalLoggerConfig(logger)
+	if err != nil {
+		return nil, err
+	}
+	return node.marshalIndent("\t"), nil
+}
+
+// LoggerConfigAsString returns the configuration of a logger as a seelog XML config.
+// See LoggerConfigAsBytes.
+func LoggerConfigAsString(logger LoggerInterface) (string, error) {
+	config, err := LoggerConfigAsBytes(logger)
+	if err != nil {
+		return "", err
+	}
+	return string(config), nil
+}
+
+func marshalLoggerConfig(logger LoggerInterface) (*xmlNode, error) {
+	config, err := configOf(logger)
+	if err != nil {
+		return nil, err
+	}
+	var logType loggerTypeFromString
+	var logData interface{}
+	switch typed := logger.(type) {
+	case *syncLogger:
+		logType = syncloggerTypeFromString
+	case *asyncLoopLogger:
+		logType = asyncLooploggerTypeFromString
+	case *asyncTimerLogger:
+		logType = asyncTimerloggerTypeFromString
+		logData = asyncTimerLoggerData{uint32(typed.interval)}
+	case *asyncAdaptiveLogger:
+		logType = adaptiveLoggerTypeFromString
+		logData = adaptiveLoggerData{uint32(typed.minInterval), uint32(typed.maxInterval), typed.criticalMsgCount}
+	default:
+		return nil, fmt.Errorf("unexpected logger type %T", logger)
+	}
+	return marshalConfig(&configForParsing{logConfig: *config, LogType: logType, LoggerData: logData})
+}
+
+// configMarshaler keeps the formats met while describing the outputs tree.
+type configMarshaler struct {
+	formatIDs   map[*formatter]string
+	usedIDs     map[string]bool
+	formatsNode *xmlNode
+}
+
+// marshalConfig describes a config as a seelog config node tree.
+func marshalConfig(config *configForParsing) (*xmlNode, error) {
+	root := newNode()
+	root.name = seelogConfigID
+
+	if err := marshalConstraints(root, config.Constraints); err != nil {
+		return nil, err
+	}
+	if err := marshalLoggerType(root, config.LogType, config.LoggerData); err != nil {
+		return nil, err
+	}
+
+	if len(config.Exceptions) != 0 {
+		exceptionsNode := newNode()
+		exceptionsNode.name = exceptionsID
+		for _, exception := range config.Exceptions {
+			exceptionNode := newNode()
+			exceptionNode.name = exceptionID
+			if exception.FuncPattern() != "*" {
+				exceptionNode.attributes[funcPatternID] = exception.FuncPattern()
+			}
+			if exception.FilePattern() != "*" {
+				exceptionNode.attributes[filePatternID] = exception.FilePattern()
+			}
+			if err := marshalConstraints(exceptionNode, exception.constraints); err != nil {
+				return nil, err
+			}
+			exceptionsNode.add(exceptionNode)
+		}
+		root.add(exceptionsNode)
+	}
+
+	marshaler := &configMarshaler{
+		formatIDs: make(map[*formatter]string),
+		usedIDs:   make(map[string]bool),
+	}
+	outputsNode, err := marshaler.marshalOutputs(config.RootDispatcher)
+	if err != nil {
+		return nil, err
+	}
+	if marshaler.formatsNode != nil {
+		root.add(marshaler.formatsNode)
+	}
+	root.add(outputsNode)
+
+	return root, nil
+}
+
+func marshalConstraints(node *xmlNode, constraints logLevelConstraints) error {
+	switch typed := constraints.(type) {
+	case *minMaxConstraints:
+		if typed.min != TraceLvl {
+			node.attributes[minLevelID] = typed.min.String()
+		}
+		if typed.max != CriticalLvl {
+			node.attributes[maxLevelID] = typed.max.String()
+		}
+	case *listConstraints:
+		levels := make([]LogLevel, 0, len(typed.allowedLevels))
+		for level, allowed := range typed.allowedLevels {
+			if allowed {
+				levels = append(levels, level)
+			}
+		}
+		sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+		node.attributes[levelsID] = levelsString(levels)
+	case *offConstraints:
+		node.attributes[levelsID] = LogLevel(Off).String()
+	default:
+		return fmt.Errorf("unexpected constraints type %T", constraints)
+	}
+	return nil
+}
+
+func marshalLoggerType(node *xmlNode, logType loggerTypeFromString, logData interface{}) error {
+	if logType == defaultloggerTypeFromString {
+		return nil
+	}
+	typeStr, ok := loggerTypeToStringRepresentations[logType]
+	if !ok {
+		return fmt.Errorf("unexpected logger type: %d", logType)
+	}
+	node.attributes[loggerTypeFromStringAttr] = typeStr
+
+	switch data := logData.(type) {
+	case nil:
+	case asyncTimerLoggerData:
+		node.attributes[asyncLoggerIntervalAttr] = strconv.FormatUint(uint64(data.AsyncInterval), 10)
+	case adaptiveLoggerData:
+		node.attributes[adaptLoggerMinIntervalAttr] = strconv.FormatUint(uint64(data.MinInterval), 10)
+		node.attributes[adaptLoggerMaxIntervalAttr] = strconv.FormatUint(uint64(data.MaxInterval), 10)
+		node.attributes[adaptLoggerCriticalMsgCountAttr] = strconv.FormatUint(uint64(data.CriticalMsgCount), 10)
+	default:
+		return fmt.Errorf("unexpected logger data type %T", logData)
+	}
+	return nil
+}
+
+// marshalOutputs describes the root dispatcher as the 'outputs' element.
+func (marshaler *configMarshaler) marshalOutputs(root dispatcherInterface) (*xmlNode, error) {
+	outputsNode := newNode()
+	outputsNode.name = outputsID
+
+	splitter, ok := root.(*splitDispatcher)
+	if !ok {
+		child, err := marshaler.marshalReceiver(root, DefaultFormatter)
+		if err != nil {
+			return nil, err
+		}
+		outputsNode.add(child)
+		return outputsNode, nil
+	}
+
+	marshaler.setFormat(outputsNode, splitter.formatter, DefaultFormatter)
+	if err := marshaler.marshalChildren(outputsNode, splitter.dispatcher); err != nil {
+		return nil, err
+	}
+	return outputsNode, nil
+}
+
+func (marshaler *configMarshaler) marshalChildren(node *xmlNode, disp *dispatcher) error {
+	for _, writer := range disp.Writers() {
+		child, err := marshaler.marshalReceiver(writer, disp.formatter)
+		if err != nil {
+			return err
+		}
+		node.add(child)
+	}
+	for _, childDisp := range disp.Dispatchers() {
+		child, err := marshaler.marshalReceiver(childDisp, disp.formatter)
+		if err != nil {
+			return err
+		}
+		node.add(child)
+	}
+	return nil
+}
+
+// marshalReceiver describes a writer or a dispatcher of the outputs tree.
+func (marshaler *configMarshaler) marshalReceiver(receiver interface{}, parentFormat *formatter) (*xmlNode, error) {
+	var node *xmlNode
+	var format *formatter
+	var err error
+
+	switch typed := receiver.(type) {
+	case *formattedWriter:
+		node, err = marshalWriter(typed.Writer())
+		format = typed.Format()
+	case *splitDispatcher:
+		node = newNode()
+		node.name = splitterDispatcherID
+		format = typed.formatter
+		err = marshaler.marshalChildren(node, typed.dispatcher)
+	case *filterDispatcher:
+		node, err = marshalFilter(typed)
+		format = typed.formatter
+		if err == nil {
+			err = marshaler.marshalChildren(node, typed.dispatcher)
+		}
+	case *redactDispatcher:
+		node = marshalRedact(typed)
+		format = typed.formatter
+		err = marshaler.marshalChildren(node, typed.dispatcher)
+	case *customReceiverDispatcher:
+		node = newNode()
+		node.name = customReceiverID
+		node.attributes[customNameAttrID] = typed.customReceiverName
+		for name, value := range typed.usedArgs.XmlCustomAttrs {
+			if secretNamePattern.MatchString(name) {
+				value = configSecretMask
+			}
+			node.attributes[customNameDataAttrPrefix+name] = value
+		}
+		if element := typed.usedArgs.Element; element != nil {
+			node.value = element.node.value
+			for _, child := range element.node.children {
+				childCopy := copyNode(child)
+				maskSecrets(childCopy)
+				node.add(childCopy)
+			}
+		}
+		format = typed.formatter
+	case *memoryWriter:
+		node = newNode()
+		node.name = memoryWriterID
+		node.attributes[memoryNameAttr] = typed.name
+		node.attributes[memoryCapacityAttr] = strconv.Itoa(len(typed.records))
+		format = typed.formatter
+	case *dynamicFileDispatcher:
+		node, err = marshalDynamicFile(typed)
+		format = typed.formatter
+	case *elementDispatcher:
+		var children []*xmlNode
+		for _, child := range typed.node.children {
+			if !isOutputElement(child.name) {
+				children = append(children, child)
+			}
+		}
+		node = marshalElement(typed.node, children)
+		format = typed.formatter
+		err = marshaler.marshalChildren(node, typed.dispatcher)
+	default:
+		return nil, fmt.Errorf("%T can not be described in a config", receiver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	marshaler.setFormat(node, format, parentFormat)
+	return node, nil
+}
+
+// setFormat sets the format id of an element, if its format differs from the inherited one.
+func (marshaler *configMarshaler) setFormat(node *xmlNode, format, parentFormat *formatter) {
+	if format == parentFormat {
+		return
+	}
+	node.attributes[outputFormatID] = marshaler.formatID(format)
+}
+
+// formatID returns the id referencing the format and declares the format, if needed.
+func (marshaler *configMarshaler) formatID(format *formatter) string {
+	if id, ok := marshaler.formatIDs[format]; ok {
+		return id
+	}
+	for id, predefined := range predefinedFormats {
+		if predefined == format {
+			marshaler.formatIDs[format] = id
+			return id
+		}
+	}
+
+	id := format.id
+	if len(id) == 0 || marshaler.usedIDs[id] || strings.HasPrefix(id, predefinedPrefix) {
+		base := id
+		if len(base) == 0 {
+			base = "format"
+		}
+		for i := 1; ; i++ {
+			id = base + strconv.Itoa(i)
+			if !marshaler.usedIDs[id] {
+				break
+			}
+		}
+	}
+	marshaler.formatIDs[format] = id
+	marshaler.usedIDs[id] = true
+
+	if marshaler.formatsNode == nil {
+		marshaler.formatsNode = newNode()
+		marshaler.formatsNode.name = formatsID
+	}
+	formatNode := newNode()
+	formatNode.name = formatID
+	formatNode.attributes[formatKeyAttrID] = id
+	formatNode.attributes[formatAttrID] = format.fmtStringOriginal
+	marshaler.formatsNode.add(formatNode)
+	return id
+}
+
+func marshalFilter(filter *filterDispatcher) (*xmlNode, error) {
+	node := newNode()
+	node.name = filterDispatcherID
+
+	if !filter.anyLevel {
+		levels := make([]LogLevel, 0, len(filter.allowList))
+		for level, allowed := range filter.allowList {
+			if allowed {
+				levels = append(levels, level)
+			}
+		}
+		if len(levels) == 0 {
+			return nil, errors.New("filter without allowed levels can not be described in a config")
+		}
+		sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+		node.attributes[filterLevelsAttrID] = levelsString(levels)
+	}
+	if filter.mode != FilterInclude {
+		node.attributes[filterModeAttrID] = filter.mode.String()
+	}
+
+	for _, rule := range filter.rules {
+		var attr, value string
+		switch typed := rule.(type) {
+		case *containsRule:
+			attr, value = filterContainsAttrID, typed.substr
+		case *regexpRule:
+			attr, value = filterRegexpAttrID, typed.expr.String()
+		case *callSiteRule:
+			attr, value = filterFuncPatternAttrID, typed.pattern
+			if typed.file {
+				attr = filterFilePatternAttrID
+			}
+		case *contextFieldRule:
+			attr, value = filterContextAttrID, typed.key+"="+typed.value
+		default:
+			return nil, fmt.Errorf("filter rule %T can not be described in a config", rule)
+		}
+		if _, ok := node.attributes[attr]; ok {
+			return nil, fmt.Errorf("filter with several '%s' rules can not be described in a config", attr)
+		}
+		node.attributes[attr] = value
+	}
+	return node, nil
+}
+
+// marshalRedact describes the leading presets with the dispatcher mode in the 'presets'
+// attribute and other rules as 'rule' elements.
+func marshalRedact(redact *redactDispatcher) *xmlNode {
+	node := newNode()
+	node.name = redactDispatcherID
+
+	mode := redact.rules[0].mode
+	if mode != RedactMask {
+		node.attributes[redactModeAttr] = mode.String()
+	}
+	if redact.rules[0].hashKey != nil {
+		node.attributes[redactHashKeyAttr] = configSecretMask
+	}
+
+	var presets []string
+	rules := redact.rules
+	for len(rules) != 0 && rules[0].mode == mode && isRedactPreset(rules[0]) {
+		presets = append(presets, rules[0].name)
+		rules = rules[1:]
+	}
+	if len(presets) != 0 {
+		node.attributes[redactPresetsAttr] = strings.Join(presets, ",")
+	}
+
+	for _, rule := range rules {
+		ruleNode := newNode()
+		ruleNode.name = redactRuleID
+		ruleNode.attributes[redactRulePatternAttr] = rule.expr.String()
+		if rule.mode != mode {
+			ruleNode.attributes[redactModeAttr] = rule.mode.String()
+		}
+		node.add(ruleNode)
+	}
+	return node
+}
+
+func isRedactPreset(rule *redactRule) bool {
+	pattern, ok := redactPresets[rule.name]
+	return ok && pattern == rule.expr.String()
+}
+
+// marshalElement describes a registered element as it was in the config it was created from,
+// with the given child elements. The format id is set from the actual format and secrets are
+// masked by maskSecrets.
+func marshalElement(element *xmlNode, children []*xmlNode) *xmlNode {
+	node := copyNode(&xmlNode{name: element.name, attributes: element.attributes, value: element.value})
+	delete(node.attributes, outputFormatID)
+	maskSecrets(node)
+	for _, child := range children {
+		childCopy := copyNode(child)
+		maskSecrets(childCopy)
+		node.add(childCopy)
+	}
+	return node
+}
+
+// maskSecrets masks the secrets of a copied element and of its children: the values of
+// attributes with secret names, the attributes and text of elements with secret names and
+// the value of name/value pair elements with secret names.
+func maskSecrets(node *xmlNode) {
+	secretElement := secretNamePattern.MatchString(node.name)
+	secretPair := secretNamePattern.MatchString(node.attributes[secretPairNameAttr])
+	for name := range node.attributes {
+		if secretElement || secretNamePattern.MatchString(name) || (secretPair && name == secretPairValueAttr) {
+			node.attributes[name] = configSecretMask
+		}
+	}
+	if (secretElement || secretPair) && len(node.value) != 0 {
+		node.value = configSecretMask
+	}
+	for _, child := range node.children {
+		maskSecrets(child)
+	}
+}
+
+func marshalDynamicFile(disp *dynamicFileDispatcher) (*xmlNode, error) {
+	pathTemplate := disp.pathFormatter.fmtStringOriginal
+
+	var node *xmlNode
+	if disp.template != nil {
+		var err error
+		node, err = marshalWriter(disp.template)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		node = newNode()
+		node.name = fileWriterID
+	}
+	if node.name == rollingfileWriterID {
+		node.attributes[rollingFilePathAttr] = pathTemplate
+	} else {
+		node.attributes[pathID] = pathTemplate
+	}
+
+	node.attributes[dynamicFileAttr] = "true"
+	if disp.maxOpen != dynamicFileDefaultMaxOpen {
+		node.attributes[dynamicFileMaxOpenAttr] = strconv.Itoa(disp.maxOpen)
+	}
+	if disp.idleTimeout != dynamicFileDefaultIdleTimeout {
+		node.attributes[dynamicFileIdleTimeoutAttr] = disp.idleTimeout.String()
+	}
+	return node, nil
+}
+
+// marshalWriter describes an io.Writer created by this package.
+func marshalWriter(writer interface{}) (*xmlNode, error) {
+	node := newNode()
+	switch typed := writer.(type) {
+	case *elementWriter:
+		return marshalElement(typed.node, typed.node.children), nil
+	case *consoleWriter:
+		node.name = consoleWriterID
+	case *fileWriter:
+		node.name = fileWriterID
+		typed.m.Lock()
+		defer typed.m.Unlock()
+		node.attributes[pathID] = typed.fileName
+		marshalReopenInterval(node, typed.reopenInterval)
+		marshalFileLock(node, typed.lock != nil)
+		marshalSyncPolicy(node, typed.syncing.policy)
+	case *rollingFileWriterSize:
+		if err := marshalRollingFileWriter(node, typed.rollingFileWriter); err != nil {
+			return nil, err
+		}
+		node.attributes[rollingFileMaxSizeAttr] = strconv.FormatInt(typed.maxFileSize, 10)
+	case *rollingFileWriterTime:
+		if err := marshalRollingFileWriter(node, typed.rollingFileWriter); err != nil {
+			return nil, err
+		}
+		node.attributes[rollingFileDataPatternAttr] = typed.timePattern
+		if typed.fullName {
+			node.attributes[rollingFileFullNameAttr] = "true"
+		}
+	case *rollingFileWriterDateSize:
+		if err := marshalRollingFileWriter(node, typed.rollingFileWriter); err != nil {
+			return nil, err
+		}
+		node.attributes[rollingFileDataPatternAttr] = typed.timePattern
+		node.attributes[rollingFileMaxSizeAttr] = strconv.FormatInt(typed.maxFileSize, 10)
+	case *bufferedWriter:
+		node.name = bufferedWriterID
+		node.attributes[bufferedSizeAttr] = strconv.Itoa(typed.bufferSize)
+		if typed.flushPeriod != 0 {
+			node.attributes[bufferedFlushPeriodAttr] = strconv.FormatInt(int64(typed.flushPeriod/time.Millisecond), 10)
+		}
+		child, err := marshalWriter(typed.innerWriter)
+		if err != nil {
+			return nil, err
+		}
+		node.add(child)
+	case *connWriter:
+		node.name = connWriterID
+		node.attributes[connWriterNetAttr] = typed.net
+		node.attributes[connWriterAddrAttr] = typed.addr
+		if typed.reconnectOnMsg {
+			node.attributes[connWriterReconnectOnMsgAttr] = "true"
+		}
+		if typed.useTLS {
+			node.attributes[connWriterUseTLSAttr] = "true"
+			if typed.configTLS != nil && typed.configTLS.InsecureSkipVerify {
+				node.attributes[connWriterInsecureSkipVerifyAttr] = "true"
+			}
+		}
+	case *smtpWriter:
+		marshalSMTPWriter(node, typed)
+	default:
+		return nil, fmt.Errorf("%T can not be described in a config", writer)
+	}
+	return node, nil
+}
+
+func marshalRollingFileWriter(node *xmlNode, rw *rollingFileWriter) error {
+	node.name = rollingfileWriterID
+	node.attributes[rollingFileTypeAttr] = rollingTypesStringRepresentation[rw.rollingType]
+	node.attributes[rollingFilePathAttr] = filepath.Join(rw.currentDirPath, rw.fileName)
+
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	if rw.maxRolls != 0 {
+		node.attributes[rollingFileMaxRollsAttr] = strconv.Itoa(rw.maxRolls)
+	}
+	if rw.nameMode != rollingNameModePostfix {
+		node.attributes[rollingFileNameModeAttr] = rollingNameModesStringRepresentation[rw.nameMode]
+	}
+	if rw.archiveType != rollingArchiveNone {
+		node.attributes[rollingFileArchiveAttr] = rollingArchiveTypesStringRepresentation[rw.archiveType]
+		node.attributes[rollingFileArchivePathAttr] = rw.archivePath
+		if rw.archiveExploded {
+			node.attributes[rollingFileArchiveExplodedAttr] = "true"
+		}
+		if rw.archiveLevel != rollingArchiveDefaultLevel {
+			node.attributes[rollingFileArchiveLevelAttr] = strconv.Itoa(rw.archiveLevel)
+		}
+	}
+	if rw.maxAge > 0 {
+		node.attributes[rollingFileMaxAgeAttr] = rw.maxAge.String()
+	}
+	if rw.maxTotalSize > 0 {
+		node.attributes[rollingFileMaxTotalSizeAttr] = strconv.FormatInt(rw.maxTotalSize, 10)
+	}
+	if rw.archiveQueue != nil {
+		node.attributes[rollingFileArchiveAsyncAttr] = "true"
+		if rw.archiveQueue.maxSize != rollingArchiveDefaultQueueSize {
+			node.attributes[rollingFileArchiveQueueAttr] = strconv.Itoa(rw.archiveQueue.maxSize)
+		}
+	}
+	if len(rw.symlinkPath) != 0 {
+		symlink := rw.symlinkPath
+		if !filepath.IsAbs(symlink) {
+			if rel, err := filepath.Rel(rw.currentDirPath, symlink); err == nil {
+				symlink = rel
+			}
+		}
+		node.attributes[rollingFileSymlinkAttr] = symlink
+	}
+	marshalReopenInterval(node, rw.reopenInterval)
+	marshalFileLock(node, rw.processLock != nil)
+	marshalSyncPolicy(node, rw.syncing.policy)
+
+	rw.hooksLock.Lock()
+	defer rw.hooksLock.Unlock()
+	if len(rw.hooks) != 0 {
+		names := make([]string, len(rw.hooks))
+		for i, hook := range rw.hooks {
+			if len(hook.name) == 0 {
+				return errors.New("roll hook added without a name can not be described in a config")
+			}
+			names[i] = hook.name
+		}
+		node.attributes[rollingFileHooksAttr] = strings.Join(names, ",")
+	}
+	return nil
+}
+
+func marshalSMTPWriter(node *xmlNode, writer *smtpWriter) {
+	node.name = smtpWriterID
+	node.attributes[senderaddressID] = writer.senderAddress
+	node.attributes[senderNameID] = writer.senderName
+	node.attributes[hostNameID] = writer.hostName
+	node.attributes[hostPortID] = writer.hostPort
+	node.attributes[userNameID] = writer.userName
+	node.attributes[userPassID] = configSecretMask
+	if writer.subject != DefaultSubjectPhrase {
+		node.attributes[subjectID] = writer.subject
+	}
+	for _, address := range writer.recipientAddresses {
+		child := newNode()
+		child.name = recipientID
+		child.attributes[addressID] = address
+		node.add(child)
+	}
+	for _, path := range writer.caCertDirPaths {
+		child := newNode()
+		child.name = cACertDirpathID
+		child.attributes[pathID] = path
+		node.add(child)
+	}
+	for _, header := range writer.mailHeaders {
+		child := newNode()
+		child.name = mailHeaderID
+		name, value := header, ""
+		if colon := strings.Index(header, ":"); colon != -1 {
+			name, value = header[:colon], strings.TrimSpace(header[colon+1:])
+		}
+		if secretNamePattern.MatchString(name) {
+			value = configSecretMask
+		}
+		child.attributes[mailHeaderNameID] = name
+		child.attributes[mailHeaderValueID] = value
+		node.add(child)
+	}
+}
+
+func marshalReopenInterval(node *xmlNode, interval time.Duration) {
+	if interval > 0 {
+		node.attributes[reopenIntervalAttr] = interval.String()
+	}
+}
+
+func marshalFileLock(node *xmlNode, locking bool) {
+	if locking {
+		node.attributes[fileLockAttr] = "true"
+	}
+}
+
+func marshalSyncPolicy(node *xmlNode, policy SyncPolicy) {
+	if policy.Mode == SyncNever {
+		return
+	}
+	node.attributes[fileSyncAttr] = policy.Mode.String()
+	switch policy.Mode {
+	case SyncLevel:
+		node.attributes[fileSyncLevelAttr] = policy.Level.String()
+	case SyncInterval:
+		node.attributes[fileSyncIntervalAttr] = policy.Interval.String()
+	}
+}
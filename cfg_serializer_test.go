@@ -0,0 +1,291 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var serializerTestConfigs = []string{
+	`<seelog/>`,
+	`<seelog type="sync" levels="off"/>`,
+	`<seelog type="asynctimer" asyncinterval="1000000" minlevel="info">
+		<outputs><console/></outputs>
+	</seelog>`,
+	`<seelog type="adaptive" mininterval="2000000" maxinterval="100000000" critmsgcount="500" levels="debug,error">
+		<exceptions>
+			<exception funcpattern="main.*" minlevel="warn"/>
+			<exception filepattern="*test*" levels="trace,critical"/>
+		</exceptions>
+		<formats>
+			<format id="common" format="%Level %Msg &lt;%File&gt;%n"/>
+			<format id="short" format="%l %Msg"/>
+		</formats>
+		<outputs formatid="common">
+			<filter levels="error,critical" formatid="short">
+				<file path="serializer_errors.log" reopeninterval="1m0s" sync="level" synclevel="error"/>
+				<buffered size="1000" flushperiod="100" formatid="std:json">
+					<rollingfile type="size" filename="serializer_size.log" maxsize="100" maxrolls="3"
+						archivetype="zip" archivepath="serializer.zip" maxage="168h0m0s" archiveasync="true"/>
+				</buffered>
+			</filter>
+			<filter levels="info" contains="user" mode="exclude" context="user=admin*">
+				<rollingfile type="date" filename="serializer_date.log" datepattern="02.01.2006" fullname="true"
+					namemode="prefix" sync="interval" syncinterval="1s"/>
+				<rollingfile type="datesize" filename="serializer_datesize.log" datepattern="2006-01" maxsize="10"/>
+			</filter>
+			<redact presets="password,email" replace="hash">
+				<rule pattern="key=(?P&lt;secret&gt;\w+)" replace="mask"/>
+				<conn net="tcp" addr=":8888" reconnectonmsg="true"/>
+			</redact>
+			<splitter formatid="std:debug-short">
+				<file path="serializer_{%Ctx(user)}.log" dynamic="true" maxopen="5" idletimeout="1m0s"/>
+			</splitter>
+		</outputs>
+	</seelog>`,
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	defer cleanupAfterCfgTest(t)
+
+	for _, config := range serializerTestConfigs {
+		parsed, err := configFromReader(strings.NewReader(config))
+		if err != nil {
+			t.Fatalf("cannot parse %s: %s", config, err)
+		}
+		node, err := marshalConfig(parsed)
+		if err != nil {
+			t.Errorf("cannot describe %s: %s", config, err)
+			parsed.RootDispatcher.Close()
+			continue
+		}
+		serialized := node.marshalIndent("\t")
+		reparsed, err := configFromReader(bytes.NewReader(serialized))
+		if err != nil {
+			t.Errorf("cannot parse the described config %s: %s", serialized, err)
+		} else {
+			if !configsAreEqual(parsed, reparsed) {
+				t.Errorf("round trip changed the config:\n* Original: %v\n* Described: %s\n* Parsed: %v", parsed, serialized, reparsed)
+			}
+			reparsed.RootDispatcher.Close()
+		}
+		parsed.RootDispatcher.Close()
+	}
+}
+
+func TestLoggerConfigAsString(t *testing.T) {
+	params := &CfgParseParams{
+		CustomReceiverProducers: map[string]CustomReceiverProducer{
+			"serializer-test": func(CustomReceiverInitArgs) (CustomReceiver, error) {
+				return &customTestReceiver{}, nil
+			},
+		},
+	}
+	logger, err := LoggerFromParamConfigAsString(`
+<seelog type="asynctimer" asyncinterval="5000000" minlevel="debug">
+	<outputs formatid="main">
+		<smtp senderaddress="a@example.com" sendername="A" hostname="mail.example.com" hostport="587"
+			username="user" password="secret-password">
+			<recipient address="b@example.com"/>
+			<header name="Priority" value="Urgent"/>
+		</smtp>
+		<custom name="serializer-test" data-url="http://example.com" data-apitoken="secret-token"/>
+	</outputs>
+	<formats>
+		<format id="main" format="%Msg"/>
+	</formats>
+</seelog>`, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	config, err := LoggerConfigAsString(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<seelog asyncinterval="5000000" minlevel="debug" type="asynctimer">
+	<formats>
+		<format format="%Msg" id="main"/>
+	</formats>
+	<outputs formatid="main">
+		<smtp hostname="mail.example.com" hostport="587" password="********" senderaddress="a@example.com" sendername="A" username="user">
+			<recipient address="b@example.com"/>
+			<header name="Priority" value="Urgent"/>
+		</smtp>
+		<custom data-apitoken="********" data-url="http://example.com" name="serializer-test"/>
+	</outputs>
+</seelog>
+`
+	if config != expected {
+		t.Errorf("unexpected config:\n%s\nexpected:\n%s", config, expected)
+	}
+}
+
+func TestLoggerConfigGeneratedFormatIDs(t *testing.T) {
+	format, err := NewFormatter("%Msg%n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	console, _ := NewConsoleWriter()
+	inner, err := NewSplitDispatcher(format, []interface{}{console})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewSplitDispatcher(DefaultFormatter, []interface{}{inner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraints, _ := NewMinMaxConstraints(TraceLvl, CriticalLvl)
+	logger := NewSyncLogger(NewLoggerConfig(constraints, nil, root))
+	defer logger.Close()
+
+	config, err := LoggerConfigAsString(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<seelog type="sync">
+	<formats>
+		<format format="%Msg%n" id="format1"/>
+	</formats>
+	<outputs>
+		<splitter formatid="format1">
+			<console/>
+		</splitter>
+	</outputs>
+</seelog>
+`
+	if config != expected {
+		t.Errorf("unexpected config:\n%s\nexpected:\n%s", config, expected)
+	}
+}
+
+func TestLoggerConfigUnnamedRollHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_unnamedhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRollingFileWriterSize(filepath.Join(dir, "app.log"), rollingArchiveNone, "", 100, 0, rollingNameModePostfix, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.AddRollHook(func(RollEvent) {})
+	root, err := NewSplitDispatcher(DefaultFormatter, []interface{}{writer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraints, _ := NewMinMaxConstraints(TraceLvl, CriticalLvl)
+	logger := NewSyncLogger(NewLoggerConfig(constraints, nil, root))
+	defer logger.Close()
+
+	if config, err := LoggerConfigAsString(logger); err == nil {
+		t.Errorf("expected an error for an unnamed roll hook, got config:\n%s", config)
+	}
+}
+
+func TestLoggerConfigMasksMailHeaders(t *testing.T) {
+	logger, err := LoggerFromConfigAsString(`
+<seelog type="sync">
+	<outputs>
+		<smtp senderaddress="a@example.com" sendername="A" hostname="mail.example.com" hostport="587"
+			username="user" password="secret-password">
+			<recipient address="b@example.com"/>
+			<header name="Authorization" value="Bearer abc"/>
+			<header name="X-Api-Token" value="def"/>
+			<header name="Priority" value="Urgent"/>
+		</smtp>
+	</outputs>
+</seelog>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	config, err := LoggerConfigAsString(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<header name="Authorization" value="********"/>`,
+		`<header name="X-Api-Token" value="********"/>`,
+		`<header name="Priority" value="Urgent"/>`,
+	} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("config does not contain %s:\n%s", expected, config)
+		}
+	}
+}
+
+// secretChildrenReceiver takes its settings from child elements, some of which are secret.
+type secretChildrenReceiver struct{ customTestReceiver }
+
+func (*secretChildrenReceiver) ArgSpec() CustomReceiverArgSpec {
+	return CustomReceiverArgSpec{Children: []string{"param", "apitoken", "endpoint"}}
+}
+
+func TestLoggerConfigMasksCustomChildren(t *testing.T) {
+	params := &CfgParseParams{
+		CustomReceiverProducers: map[string]CustomReceiverProducer{
+			"secret-children": func(CustomReceiverInitArgs) (CustomReceiver, error) {
+				return &secretChildrenReceiver{customTestReceiver{&customTestReceiverOutput{}}}, nil
+			},
+		},
+	}
+	logger, err := LoggerFromParamConfigAsString(`
+<seelog type="sync">
+	<outputs>
+		<custom name="secret-children">
+			<param name="password" value="p1"/>
+			<param name="region" value="eu"/>
+			<apitoken>t1</apitoken>
+			<endpoint url="http://example.com" secret="s1"/>
+		</custom>
+	</outputs>
+</seelog>`, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	config, err := LoggerConfigAsString(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<custom name="secret-children">
+			<param name="password" value="********"/>
+			<param name="region" value="eu"/>
+			<apitoken>********</apitoken>
+			<endpoint secret="********" url="http://example.com"/>
+		</custom>`
+	if !strings.Contains(config, expected) {
+		t.Errorf("config does not contain\n%s\ngot\n%s", expected, config)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+)
+
+// ValidateConfigAsFile checks a config file and returns all the problems found in it as
+// ConfigErrors, or nil if the config is valid. Included files are checked as well.
+//
+// Unlike the LoggerFrom functions, validation does not stop at the first error, and it
+// creates no logger: no files or sockets are opened and custom receivers are not initialized,
+// only their names and declared args are checked. Variables are expanded as usual, so variables missing in
+// the validation environment must be set in parserParams.
+func ValidateConfigAsFile(fileName string, parserParams *CfgParseParams) error {
+	cfg := newValidationParams(parserParams)
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, err := unmarshalConfigFile(file, fileName)
+	if err != nil {
+		return ConfigErrors{{File: fileName, Err: err}}
+	}
+	setNodeFile(config, fileName)
+	return validateRootNode(config, filepath.Dir(fileName), cfg)
+}
+
+// ValidateConfigAsBytes checks a config like ValidateConfigAsFile does.
+func ValidateConfigAsBytes(data []byte, parserParams *CfgParseParams) error {
+	cfg := newValidationParams(parserParams)
+	config, err := unmarshalConfigFile(bytes.NewReader(data), "")
+	if err != nil {
+		return ConfigErrors{toConfigError(err)}
+	}
+	return validateRootNode(config, "", cfg)
+}
+
+// ValidateConfigAsString checks a config like ValidateConfigAsFile does.
+func ValidateConfigAsString(data string, parserParams *CfgParseParams) error {
+	return ValidateConfigAsBytes([]byte(data), parserParams)
+}
+
+func newValidationParams(parserParams *CfgParseParams) *CfgParseParams {
+	cfg := new(CfgParseParams)
+	if parserParams != nil {
+		*cfg = *parserParams
+	}
+	cfg.collected = new(ConfigErrors)
+	return cfg
+}
+
+func validateRootNode(config *xmlNode, baseDir string, cfg *CfgParseParams) error {
+	conf, err := configFromRootNode(config, baseDir, cfg)
+	if err != nil {
+		if errs, ok := err.(ConfigErrors); ok {
+			return errs
+		}
+		return ConfigErrors{toConfigError(err)}
+	}
+	conf.RootDispatcher.Close()
+	return nil
+}
+
+func toConfigError(err error) *ConfigError {
+	if configErr, ok := err.(*ConfigError); ok {
+		return configErr
+	}
+	if syntaxErr, ok := err.(*xml.SyntaxError); ok {
+		return &ConfigError{Line: syntaxErr.Line, Err: err}
+	}
+	return &ConfigError{Err: err}
+}
+
+// validating returns true if the params are used to validate a config.
+func (cfg *CfgParseParams) validating() bool {
+	return cfg != nil && cfg.collected != nil
+}
+
+// collectError records the error of the node if the params are used to validate a config and
+// returns true, so that the parser goes on with other elements. Otherwise it returns false and
+// the error stops parsing.
+func (cfg *CfgParseParams) collectError(node *xmlNode, err error) bool {
+	if !cfg.validating() {
+		return false
+	}
+	switch typed := err.(type) {
+	case ConfigErrors:
+		*cfg.collected = append(*cfg.collected, typed...)
+	default:
+		if err != errConfigErrorsCollected {
+			*cfg.collected = append(*cfg.collected, toConfigError(locateError(node, err)))
+		}
+	}
+	return true
+}
+
+// collectedErrors returns the errors collected during validation, nil if there are none.
+func (cfg *CfgParseParams) collectedErrors() error {
+	if !cfg.validating() || len(*cfg.collected) == 0 {
+		return nil
+	}
+	return *cfg.collected
+}
+
+// validationReceiver replaces custom receivers during validation.
+type validationReceiver struct{}
+
+func (*validationReceiver) ReceiveMessage(string, LogLevel, LogContextInterface) error { return nil }
+func (*validationReceiver) AfterParse(CustomReceiverInitArgs) error                    { return nil }
+func (*validationReceiver) Flush()                                                     {}
+func (*validationReceiver) Close() error                                               { return nil }
+
+// newValidationReceiverDispatcher checks that a custom receiver is known and that the args
+// declared by it are set, without initializing the receiver.
+func newValidationReceiverDispatcher(format *formatter, name string, args CustomReceiverInitArgs,
+	cfg *CfgParseParams) (*customReceiverDispatcher, error) {
+	// Receivers are created without AfterParse only to check their declared args.
+	var receiver CustomReceiver
+	var err error
+	if producer, ok := cfg.CustomReceiverProducers[name]; ok {
+		receiver, err = producer(args)
+	} else {
+		receiver, err = customReceiverByName(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCustomReceiverArgs(receiver, args); err != nil {
+		return nil, err
+	}
+	return NewCustomReceiverDispatcherByValue(format, &validationReceiver{}, name, args)
+}
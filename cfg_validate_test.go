@@ -0,0 +1,153 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigCollectsErrors(t *testing.T) {
+	config := `<seelog type="unknown" minlevel="info">
+	<exceptions>
+		<exception funcpattern="main.*" minlevel="loud"/>
+		<exception filepattern="*.go" minlevel="debug"/>
+	</exceptions>
+	<formats>
+		<format id="bad" format="%UnknownVerb"/>
+	</formats>
+	<outputs formatid="bad">
+		<console unknown="1"/>
+		<filter levels="error">
+			<file/>
+			<custom name="validate-unknown"/>
+		</filter>
+		<file path="validate.log"/>
+	</outputs>
+</seelog>`
+
+	err := ValidateConfigAsString(config, nil)
+	errs, ok := err.(ConfigErrors)
+	if !ok {
+		t.Fatalf("expected ConfigErrors, got %T: %v", err, err)
+	}
+	expected := []struct {
+		path string
+		line int
+	}{
+		{"/seelog/exceptions/exception[1]", 3},
+		{"/seelog/formats/format", 7},
+		{"/seelog/outputs/console", 10},
+		{"/seelog/outputs/filter/file", 12},
+		{"/seelog/outputs/filter/custom", 13},
+		{"/seelog", 1},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d:\n%v", len(expected), len(errs), errs)
+	}
+	for i, test := range expected {
+		if errs[i].Path != test.path || errs[i].Line != test.line {
+			t.Errorf("error %d: expected %s at line %d, got: %v", i, test.path, test.line, errs[i])
+		}
+	}
+	if !strings.HasPrefix(errs[2].Error(), "line 10, column 3: /seelog/outputs/console: ") {
+		t.Errorf("unexpected error message: %s", errs[2])
+	}
+}
+
+func TestValidateConfigOpensNoFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "seelog.xml")
+	config := `<seelog>
+	<outputs>
+		<file path="` + filepath.Join(dir, "file.log") + `" sync="write"/>
+		<buffered size="100" flushperiod="10">
+			<rollingfile type="size" filename="` + filepath.Join(dir, "rolling.log") + `" maxsize="10" symlink="current.log"/>
+		</buffered>
+		<conn net="tcp" addr="127.0.0.1:1"/>
+		<custom name="validate-custom"/>
+	</outputs>
+</seelog>`
+	if err := ioutil.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Produced receivers are only created to check their declared args.
+	receiver := &customTestReceiver{}
+	params := &CfgParseParams{
+		CustomReceiverProducers: map[string]CustomReceiverProducer{
+			"validate-custom": func(CustomReceiverInitArgs) (CustomReceiver, error) {
+				return receiver, nil
+			},
+		},
+	}
+	if err := ValidateConfigAsFile(configPath, params); err != nil {
+		t.Fatal(err)
+	}
+	if receiver.co != nil {
+		t.Error("custom receiver must not be initialized by validation")
+	}
+	names, err := getDirFilePaths(dir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Errorf("validation created files: %v", names)
+	}
+}
+
+func TestValidateConfigFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "seelog.xml")
+	if err := ioutil.WriteFile(configPath, []byte("<seelog>\n<outputs><file/></outputs>\n</seelog>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateConfigAsFile(configPath, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), configPath+":2:10: /seelog/outputs/file: ") {
+		t.Errorf("expected an error with the file position, got: %v", err)
+	}
+
+	err = ValidateConfigAsString("<seelog>\n<outputs>\n</seelog>", nil)
+	errs, ok := err.(ConfigErrors)
+	if !ok || len(errs) != 1 || errs[0].Line != 3 {
+		t.Errorf("expected a syntax error at line 3, got: %v", err)
+	}
+
+	if err := ValidateConfigAsString(`{"seelog": {"outputs": {"console": {}}}}`, nil); err != nil {
+		t.Errorf("unexpected error in a valid JSON config: %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+/*
+Command seelog checks seelog config files and explains how they behave.
+
+Usage:
+
+  seelog validate [flags] config...
+  seelog explain [flags] config
+  seelog test-level [flags] -func pkg.Foo -file foo.go -level debug config
+
+'validate' reports every problem found in the configs, with its file, line and element path,
+and exits with status 1 if any config is invalid. 'explain' prints the logger type, the
+SEELOG_* environment overrides applied, the level rules in the order they are checked, the
+formats in use and the outputs tree with the effective format of every receiver. 'test-level'
+tells whether a message logged from the given function and file with the given level would be
+allowed and which rule decides it. Environment overrides apply to all the subcommands.
+
+All the subcommands accept these flags:
+
+  -var NAME=VALUE  sets a config variable; may be repeated
+  -env             takes the variables not set with -var from the environment
+  -receiver NAME   declares a custom receiver registered by the application; may be repeated
+  -profile NAME    selects a config profile instead of the SEELOG_PROFILE environment variable
+
+No files or sockets are opened and custom receivers are not created.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cihub/seelog"
+)
+
+const usage = `usage:
+  seelog validate [flags] config...
+  seelog explain [flags] config
+  seelog test-level [flags] -func pkg.Foo -file foo.go -level debug config
+`
+
+// errInvalid reports that the command found a problem and printed it already.
+var errInvalid = errors.New("invalid")
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case err == errInvalid:
+		os.Exit(1)
+	case err == flag.ErrHelp:
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "seelog:", err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return flag.ErrHelp
+	}
+	switch args[0] {
+	case "validate":
+		return validate(args[1:], stdout, stderr)
+	case "explain":
+		return explain(args[1:], stdout, stderr)
+	case "test-level":
+		return testLevel(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+	fmt.Fprint(stderr, usage)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// paramsFlags holds the flags shared by all the subcommands.
+type paramsFlags struct {
+	variables variablesFlag
+	env       bool
+	receivers receiversFlag
+	profile   string
+}
+
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *paramsFlags) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	params := &paramsFlags{variables: make(variablesFlag), receivers: make(receiversFlag)}
+	flags.Var(params.variables, "var", "set a config variable, as `NAME=VALUE`")
+	flags.BoolVar(&params.env, "env", false, "take the variables not set with -var from the environment")
+	flags.Var(params.receivers, "receiver", "declare a custom receiver `NAME` registered by the application")
+	flags.StringVar(&params.profile, "profile", "", "select the config profile `NAME`")
+	return flags, params
+}
+
+func (params *paramsFlags) parseParams() *seelog.CfgParseParams {
+	return &seelog.CfgParseParams{
+		Variables:               params.variables,
+		EnvVariables:            params.env,
+		CustomReceiverProducers: params.receivers,
+		Profile:                 params.profile,
+	}
+}
+
+func validate(args []string, stdout, stderr io.Writer) error {
+	flags, params := newFlagSet("validate", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("validate: no config files")
+	}
+
+	invalid := false
+	for _, fileName := range flags.Args() {
+		err := seelog.ValidateConfigAsFile(fileName, params.parseParams())
+		if err == nil {
+			fmt.Fprintf(stdout, "%s: ok\n", fileName)
+			continue
+		}
+		invalid = true
+		if _, ok := err.(seelog.ConfigErrors); !ok {
+			err = fmt.Errorf("%s: %s", fileName, err)
+		}
+		fmt.Fprintln(stderr, err)
+	}
+	if invalid {
+		return errInvalid
+	}
+	return nil
+}
+
+func explain(args []string, stdout, stderr io.Writer) error {
+	flags, params := newFlagSet("explain", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	inspector, err := inspect(flags, params, stderr)
+	if err != nil {
+		return err
+	}
+	explanation, err := inspector.Explain()
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(stdout, explanation)
+	return nil
+}
+
+func testLevel(args []string, stdout, stderr io.Writer) error {
+	flags, params := newFlagSet("test-level", stderr)
+	funcName := flags.String("func", "", "full name of the logging function, like `pkg.Foo`")
+	file := flags.String("file", "", "`path` of the logging source file")
+	levelStr := flags.String("level", "", "message `level`")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	level, ok := seelog.LogLevelFromString(*levelStr)
+	if !ok || level == seelog.Off {
+		return fmt.Errorf("test-level: unknown level %q", *levelStr)
+	}
+	inspector, err := inspect(flags, params, stderr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, inspector.TestLevel(*funcName, *file, level))
+	return nil
+}
+
+// inspect parses the only config file named in the arguments.
+func inspect(flags *flag.FlagSet, params *paramsFlags, stderr io.Writer) (*seelog.ConfigInspector, error) {
+	if flags.NArg() != 1 {
+		return nil, fmt.Errorf("%s: expected one config file", flags.Name())
+	}
+	fileName := flags.Arg(0)
+	inspector, err := seelog.InspectConfigAsFile(fileName, params.parseParams())
+	if err != nil {
+		if _, ok := err.(seelog.ConfigErrors); ok {
+			fmt.Fprintln(stderr, err)
+			return nil, errInvalid
+		}
+		return nil, err
+	}
+	return inspector, nil
+}
+
+// variablesFlag collects '-var NAME=VALUE' flags.
+type variablesFlag map[string]string
+
+func (variables variablesFlag) String() string {
+	pairs := make([]string, 0, len(variables))
+	for name, value := range variables {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (variables variablesFlag) Set(value string) error {
+	eq := strings.Index(value, "=")
+	if eq <= 0 {
+		return fmt.Errorf("expected NAME=VALUE, got %q", value)
+	}
+	variables[value[:eq]] = value[eq+1:]
+	return nil
+}
+
+// receiversFlag collects '-receiver NAME' flags. The receivers of the application are never
+// created by this command: their producers return placeholders which accept any args.
+type receiversFlag map[string]seelog.CustomReceiverProducer
+
+func (receivers receiversFlag) String() string {
+	names := make([]string, 0, len(receivers))
+	for name := range receivers {
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (receivers receiversFlag) Set(name string) error {
+	receivers[name] = newPlaceholderReceiver
+	return nil
+}
+
+// placeholderReceiver stands for a custom receiver of the application and declares the args
+// it was produced with, so that any '<custom>' element of that receiver is valid.
+type placeholderReceiver struct {
+	spec seelog.CustomReceiverArgSpec
+}
+
+func newPlaceholderReceiver(args seelog.CustomReceiverInitArgs) (seelog.CustomReceiver, error) {
+	receiver := &placeholderReceiver{}
+	for name := range args.XmlCustomAttrs {
+		receiver.spec.Optional = append(receiver.spec.Optional, name)
+	}
+	if args.Element != nil {
+		for _, child := range args.Element.Children() {
+			receiver.spec.Children = append(receiver.spec.Children, child.Name())
+		}
+	}
+	return receiver, nil
+}
+
+func (receiver *placeholderReceiver) ArgSpec() seelog.CustomReceiverArgSpec { return receiver.spec }
+
+func (*placeholderReceiver) ReceiveMessage(string, seelog.LogLevel, seelog.LogContextInterface) error {
+	return nil
+}
+func (*placeholderReceiver) AfterParse(seelog.CustomReceiverInitArgs) error { return nil }
+func (*placeholderReceiver) Flush()                                         {}
+func (*placeholderReceiver) Close() error                                   { return nil }
@@ -0,0 +1,102 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, dir, name, config string) string {
+	fileName := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(fileName, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestRunTestLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog-cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := writeTestConfig(t, dir, "seelog.xml", `<seelog minlevel="info">
+	<exceptions><exception funcpattern="main.debug*" minlevel="debug"/></exceptions>
+	<outputs><file path="${DIR}/app.log"/><custom name="app"/></outputs>
+	<profile name="prod" minlevel="warn"/>
+</seelog>`)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"test-level", "-var", "DIR=/nonexistent", "-receiver", "app",
+		"-func", "main.debugHandler", "-file", "main.go", "-level", "debug", fileName}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("%s: %s", err, stderr.String())
+	}
+	expected := `allowed by exception 1 (funcpattern="main.debug*" filepattern="*": minlevel="debug")` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("got %q, expected %q", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	args = []string{"test-level", "-var", "DIR=/nonexistent", "-receiver", "app", "-profile", "prod",
+		"-func", "main.handler", "-file", "main.go", "-level", "info", fileName}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("%s: %s", err, stderr.String())
+	}
+	expected = `denied by general constraints (minlevel="warn")` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("got %q, expected %q", stdout.String(), expected)
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog-cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	valid := writeTestConfig(t, dir, "valid.xml", `<seelog><outputs>
+	<console/>
+	<custom name="app" data-service="billing"><endpoint url="http://a"/></custom>
+</outputs></seelog>`)
+	invalid := writeTestConfig(t, dir, "invalid.xml", `<seelog minlevel="loud"><outputs><custom name="unknown"/></outputs></seelog>`)
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"validate", "-receiver", "app", valid, invalid}, &stdout, &stderr); err != errInvalid {
+		t.Fatalf("expected errInvalid, got %v", err)
+	}
+	if stdout.String() != valid+": ok\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); len(lines) != 2 {
+		t.Errorf("expected two errors, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package seelog
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time used by loggers and writers: call time of messages,
+// date rolling, buffered writer flush periods and async logger intervals.
+//
+// The default clock is SystemClock. Use CfgParseParams.Clock or ClockSetter
+// to replace it, e.g. with a ManualClock in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// ClockSetter is implemented by the loggers and the time-dependent writers of this
+// package. Loggers created by the LoggerFrom* funcs may be checked for it:
+//
+//	if setter, ok := logger.(seelog.ClockSetter); ok {
+//		setter.SetClock(clock)
+//	}
+//
+// SetClock with a nil clock restores SystemClock.
+type ClockSetter interface {
+	SetClock(clock Clock)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) newTimer(d time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+// stoppableClock is implemented by clocks whose waits can be cancelled, so that
+// background goroutines leave no pending waits behind.
+type stoppableClock interface {
+	newTimer(d time.Duration) (<-chan time.Time, func())
+}
+
+// newClockTimer waits for the duration like clock.After and also returns a func
+// cancelling the wait, if the clock supports it.
+func newClockTimer(clock Clock, d time.Duration) (<-chan time.Time, func()) {
+	if stoppable, ok := clock.(stoppableClock); ok {
+		return stoppable.newTimer(d)
+	}
+	return clock.After(d), func() {}
+}
+
+// SystemClock is the Clock based on the real system time.
+var SystemClock Clock = systemClock{}
+
+type manualClockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// ManualClock is a Clock which time only changes when Set or Advance is called.
+// Channels returned by After fire when the clock is moved past their deadlines.
+type ManualClock struct {
+	now     time.Time
+	waiters []*manualClockWaiter
+	m       sync.Mutex
+}
+
+// NewManualClock creates a manual clock showing the specified time.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (clock *ManualClock) Now() time.Time {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	return clock.now
+}
+
+// After returns a channel which receives the clock time once the clock
+// is moved by at least d.
+func (clock *ManualClock) After(d time.Duration) <-chan time.Time {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	return clock.addWaiter(d).ch
+}
+
+func (clock *ManualClock) newTimer(d time.Duration) (<-chan time.Time, func()) {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	waiter := clock.addWaiter(d)
+	return waiter.ch, func() { clock.removeWaiter(waiter) }
+}
+
+func (clock *ManualClock) addWaiter(d time.Duration) *manualClockWaiter {
+	waiter := &manualClockWaiter{clock.now.Add(d), make(chan time.Time, 1)}
+	if d <= 0 {
+		waiter.ch <- clock.now
+		return waiter
+	}
+	clock.waiters = append(clock.waiters, waiter)
+	return waiter
+}
+
+func (clock *ManualClock) removeWaiter(waiter *manualClockWaiter) {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	for i, pending := range clock.waiters {
+		if pending == waiter {
+			clock.waiters = append(clock.waiters[:i], clock.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// Advance moves the clock forward by d.
+func (clock *ManualClock) Advance(d time.Duration) {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	clock.set(clock.now.Add(d))
+}
+
+// Set changes the current time of the clock.
+func (clock *ManualClock) Set(now time.Time) {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	clock.set(now)
+}
+
+// Waiters returns the number of pending After calls. Tests may use it to
+// make sure that a background goroutine is waiting before moving the clock.
+func (clock *ManualClock) Waiters() int {
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	return len(clock.waiters)
+}
+
+func (clock *ManualClock) set(now time.Time) {
+	clock.now = now
+
+	pending := clock.waiters[:0]
+	for _, waiter := range clock.waiters {
+		if waiter.deadline.After(now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.ch <- now
+	}
+	clock.waiters = pending
+}
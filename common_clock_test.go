@@ -0,0 +1,101 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package seelog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualClockAfter(t *testing.T) {
+	start := time.Date(2015, time.March, 1, 10, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	ch := clock.After(time.Minute)
+	if clock.Waiters() != 1 {
+		t.Fatalf("expected 1 waiter, got %d", clock.Waiters())
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("channel fired before the deadline")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case now := <-ch:
+		if !now.Equal(start.Add(time.Minute)) {
+			t.Errorf("expected %v, got %v", start.Add(time.Minute), now)
+		}
+	default:
+		t.Fatal("channel did not fire after the deadline")
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("expected no waiters, got %d", clock.Waiters())
+	}
+
+	select {
+	case <-clock.After(0):
+	default:
+		t.Error("After(0) must fire immediately")
+	}
+}
+
+func TestLoggerCallTimeFromClock(t *testing.T) {
+	callTime := time.Date(2015, time.March, 1, 10, 0, 0, 0, time.UTC)
+	logger, err := LoggerFromParamConfigAsString(`
+<seelog type="sync">
+	<outputs formatid="msg">
+		<memory name="clock" capacity="10"/>
+	</outputs>
+	<formats>
+		<format id="msg" format="%Msg"/>
+	</formats>
+</seelog>`, &CfgParseParams{Clock: NewManualClock(callTime)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logger.Info("first")
+	output, err := MemoryOutputByName(logger, "clock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := output.Snapshot(nil)
+	if len(records) != 1 || !records[0].Time.Equal(callTime) {
+		t.Fatalf("expected one record at %v, got %v", callTime, records)
+	}
+
+	later := callTime.Add(time.Hour)
+	logger.(ClockSetter).SetClock(NewManualClock(later))
+	logger.Info("second")
+	records = output.Snapshot(nil)
+	if len(records) != 2 || !records[1].Time.Equal(later) {
+		t.Fatalf("expected second record at %v, got %v", later, records)
+	}
+}
@@ -73,7 +73,7 @@ type LogContextInterface interface {
 
 // Returns context of the caller
 func currentContext(custom interface{}) (LogContextInterface, error) {
-	return specifyContext(1, custom)
+	return specifyContext(1, custom, SystemClock)
 }
 
 func extractCallerInfo(skip int) (*logContext, error) {
@@ -127,12 +127,12 @@ func extractCallerInfo(skip int) (*logContext, error) {
 }
 
 // Returns context of the function with placed "skip" stack frames of the caller
-// If skip == 0 then behaves like currentContext
+// If skip == 0 then behaves like currentContext. Call time is taken from clock.
 // Context is returned in any situation, even if error occurs. But, if an error
 // occurs, the returned context is an error context, which contains no paths
 // or names, but states that they can't be extracted.
-func specifyContext(skip int, custom interface{}) (LogContextInterface, error) {
-	callTime := time.Now()
+func specifyContext(skip int, custom interface{}, clock Clock) (LogContextInterface, error) {
+	callTime := clock.Now()
 	if skip < 0 {
 		err := fmt.Errorf("can not skip negative stack frames")
 		return &errorContext{callTime, err}, err
@@ -90,6 +90,71 @@ type CustomReceiverInitArgs struct {
 	// your custom attributes, starting with "data-". Any other will lead to a
 	// parsing error.
 	XmlCustomAttrs map[string]string
+
+	// Element is the '<custom>' config element. It gives access to the child elements and
+	// the text content of the element, to typed values of its attributes (with the 'data-'
+	// prefix) and to the format of the receiver. Element is nil if the receiver is not
+	// created from a config.
+	Element *ConfigElement
+}
+
+// CustomReceiverArgSpec declares the arguments of a custom receiver in a config.
+type CustomReceiverArgSpec struct {
+	// Required and Optional list the names of 'data-' attributes, without the prefix.
+	Required []string
+	Optional []string
+
+	// Children lists the names of the allowed child elements. Receivers which do not
+	// declare their args cannot have child elements.
+	Children []string
+}
+
+// CustomReceiverArgDeclarer is implemented by custom receivers which declare their arguments.
+// The parser checks the '<custom>' element against the declared arguments before calling
+// AfterParse: missing required attributes, undeclared attributes and undeclared child
+// elements are config errors. ArgSpec is called on a new receiver and must not depend on
+// its state, so that configs can be validated without creating receivers.
+type CustomReceiverArgDeclarer interface {
+	ArgSpec() CustomReceiverArgSpec
+}
+
+// RawMessageReceiver is implemented by custom receivers which need the message as it was
+// logged in addition to the formatted one. For such receivers ReceiveRawMessage is called
+// instead of ReceiveMessage.
+type RawMessageReceiver interface {
+	ReceiveRawMessage(message, rawMessage string, level LogLevel, context LogContextInterface) error
+}
+
+// checkCustomReceiverArgs checks the init args against the args declared by the receiver, if any.
+// Only receivers which declare child elements can have them.
+func checkCustomReceiverArgs(receiver CustomReceiver, args CustomReceiverInitArgs) error {
+	declarer, ok := receiver.(CustomReceiverArgDeclarer)
+	if !ok {
+		if args.Element != nil && len(args.Element.children) != 0 {
+			return errNodeCannotHaveChildren
+		}
+		return nil
+	}
+	spec := declarer.ArgSpec()
+	for _, name := range spec.Required {
+		if _, ok := args.XmlCustomAttrs[name]; !ok {
+			return newMissingArgumentError(customReceiverID, customNameDataAttrPrefix+name)
+		}
+	}
+	names := make([]string, 0, len(args.XmlCustomAttrs))
+	for name := range args.XmlCustomAttrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !stringInSlice(name, spec.Required) && !stringInSlice(name, spec.Optional) {
+			return newUnexpectedAttributeError(customReceiverID, customNameDataAttrPrefix+name)
+		}
+	}
+	if args.Element != nil {
+		return args.Element.CheckChildren(spec.Children...)
+	}
+	return nil
 }
 
 // CustomReceiver is the interface that external custom seelog message receivers
@@ -165,6 +230,10 @@ func NewCustomReceiverDispatcher(formatter *formatter, customReceiverName string
 	if err != nil {
 		return nil, err
 	}
+	err = checkCustomReceiverArgs(creceiver, cArgs)
+	if err != nil {
+		return nil, err
+	}
 	err = creceiver.AfterParse(cArgs)
 	if err != nil {
 		return nil, err
@@ -201,7 +270,13 @@ func (disp *customReceiverDispatcher) Dispatch(
 		}
 	}()
 
-	err := disp.innerReceiver.ReceiveMessage(disp.formatter.Format(message, level, context), level, context)
+	formatted := disp.formatter.Format(message, level, context)
+	var err error
+	if rawReceiver, ok := disp.innerReceiver.(RawMessageReceiver); ok {
+		err = rawReceiver.ReceiveRawMessage(formatted, message, level, context)
+	} else {
+		err = disp.innerReceiver.ReceiveMessage(formatted, level, context)
+	}
 	if err != nil {
 		errorFunc(err)
 	}
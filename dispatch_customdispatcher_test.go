@@ -25,6 +25,7 @@
 package seelog
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -175,3 +176,161 @@ func TestCustomDispatcher_Close(t *testing.T) {
 		return
 	}
 }
+
+// testEndpointsReceiver declares its args and takes endpoints from child elements.
+type testEndpointsReceiver struct {
+	service   string
+	endpoints []string
+	header    string
+	messages  []string
+}
+
+func (receiver *testEndpointsReceiver) ArgSpec() CustomReceiverArgSpec {
+	return CustomReceiverArgSpec{
+		Required: []string{"service"},
+		Optional: []string{"timeout"},
+		Children: []string{"endpoint", "header"},
+	}
+}
+
+func (receiver *testEndpointsReceiver) AfterParse(args CustomReceiverInitArgs) error {
+	receiver.service = args.XmlCustomAttrs["service"]
+	for _, child := range args.Element.Children() {
+		switch child.Name() {
+		case "endpoint":
+			url, err := child.RequiredAttr("url")
+			if err != nil {
+				return err
+			}
+			receiver.endpoints = append(receiver.endpoints, url)
+		case "header":
+			receiver.header = child.Value()
+		}
+	}
+	return nil
+}
+
+func (receiver *testEndpointsReceiver) ReceiveMessage(message string, level LogLevel, context LogContextInterface) error {
+	receiver.messages = append(receiver.messages, message)
+	return nil
+}
+
+func (receiver *testEndpointsReceiver) ReceiveRawMessage(message, rawMessage string, level LogLevel, context LogContextInterface) error {
+	receiver.messages = append(receiver.messages, message+"|"+rawMessage)
+	return nil
+}
+
+func (receiver *testEndpointsReceiver) Flush()       {}
+func (receiver *testEndpointsReceiver) Close() error { return nil }
+
+func TestCustomReceiverElement(t *testing.T) {
+	RegisterReceiver("TestCustomReceiverElement", &testEndpointsReceiver{})
+
+	config, err := configFromReader(strings.NewReader(`<seelog>
+	<outputs formatid="level">
+		<custom name="TestCustomReceiverElement" data-service="billing">
+			<endpoint url="http://a"/>
+			<endpoint url="http://b"/>
+			<header>X-Team: ops</header>
+		</custom>
+	</outputs>
+	<formats>
+		<format id="level" format="%l %Msg"/>
+	</formats>
+</seelog>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var receiver *testEndpointsReceiver
+	walkOutputs(config.RootDispatcher, func(output interface{}) bool {
+		if custom, ok := output.(*customReceiverDispatcher); ok {
+			receiver = custom.innerReceiver.(*testEndpointsReceiver)
+		}
+		return receiver == nil
+	})
+	if receiver == nil {
+		t.Fatal("custom receiver not found")
+	}
+	if receiver.service != "billing" || strings.Join(receiver.endpoints, ",") != "http://a,http://b" || receiver.header != "X-Team: ops" {
+		t.Errorf("unexpected init args: %+v", receiver)
+	}
+
+	config.RootDispatcher.Dispatch("hello", InfoLvl, &inspectContext{}, func(err error) { t.Error(err) })
+	if len(receiver.messages) != 1 || receiver.messages[0] != "i hello|hello" {
+		t.Errorf("unexpected messages %q", receiver.messages)
+	}
+
+	node, err := marshalConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<custom data-service="billing" name="TestCustomReceiverElement">
+			<endpoint url="http://a"/>
+			<endpoint url="http://b"/>
+			<header>X-Team: ops</header>
+		</custom>`
+	if marshaled := string(node.marshalIndent("\t")); !strings.Contains(marshaled, expected) {
+		t.Errorf("serialized config does not contain\n%s\ngot\n%s", expected, marshaled)
+	}
+}
+
+func TestCustomReceiverDeclaredArgs(t *testing.T) {
+	RegisterReceiver("TestCustomReceiverDeclaredArgs", &testEndpointsReceiver{})
+
+	tests := []struct {
+		custom string
+		err    string
+	}{
+		{`<custom name="TestCustomReceiverDeclaredArgs"/>`, "has no 'data-service' attribute"},
+		{`<custom name="TestCustomReceiverDeclaredArgs" data-service="s" data-retries="3"/>`, "data-retries"},
+		{`<custom name="TestCustomReceiverDeclaredArgs" data-service="s"><url/></custom>`, "/seelog/outputs/custom/url: "},
+	}
+	for _, test := range tests {
+		config := "<seelog><outputs>" + test.custom + "</outputs></seelog>"
+		for _, validate := range []bool{false, true} {
+			var err error
+			if validate {
+				err = ValidateConfigAsString(config, nil)
+			} else {
+				_, err = configFromReader(strings.NewReader(config))
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s (validate: %t): expected error containing %q, got %v", test.custom, validate, test.err, err)
+			}
+		}
+	}
+}
+
+func TestCustomReceiverUndeclaredChildren(t *testing.T) {
+	RegisterReceiver("TestCustomReceiverUndeclaredChildren", &customTestReceiver{})
+	producers := map[string]CustomReceiverProducer{
+		"produced-endpoints": func(CustomReceiverInitArgs) (CustomReceiver, error) {
+			return &testEndpointsReceiver{}, nil
+		},
+	}
+
+	tests := []struct {
+		custom string
+		err    string
+	}{
+		{`<custom name="TestCustomReceiverUndeclaredChildren"><endpoint/></custom>`, errNodeCannotHaveChildren.Error()},
+		{`<custom name="produced-endpoints"/>`, "has no 'data-service' attribute"},
+		{`<custom name="produced-endpoints" data-service="s"><url/></custom>`, "/seelog/outputs/custom/url: "},
+	}
+	for _, test := range tests {
+		config := "<seelog><outputs>" + test.custom + "</outputs></seelog>"
+		params := &CfgParseParams{CustomReceiverProducers: producers}
+		for _, validate := range []bool{false, true} {
+			var err error
+			if validate {
+				err = ValidateConfigAsString(config, params)
+			} else {
+				_, err = configFromReaderWithConfig(strings.NewReader(config), params)
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s (validate: %t): expected error containing %q, got %v", test.custom, validate, test.err, err)
+			}
+		}
+	}
+}
@@ -158,6 +158,35 @@ func (disp *dispatcher) Dispatchers() []dispatcherInterface {
 	return disp.dispatchers
 }
 
+// dispatcherNode is implemented by dispatchers that hold child receivers.
+type dispatcherNode interface {
+	Writers() []*formattedWriter
+	Dispatchers() []dispatcherInterface
+}
+
+// walkOutputs calls visit for every dispatcher and formatted writer in the tree
+// starting from root (root included). Walking stops as soon as visit returns false.
+func walkOutputs(root dispatcherInterface, visit func(output interface{}) bool) bool {
+	if !visit(root) {
+		return false
+	}
+	node, ok := root.(dispatcherNode)
+	if !ok {
+		return true
+	}
+	for _, writer := range node.Writers() {
+		if !visit(writer) {
+			return false
+		}
+	}
+	for _, disp := range node.Dispatchers() {
+		if !walkOutputs(disp, visit) {
+			return false
+		}
+	}
+	return true
+}
+
 func (disp *dispatcher) String() string {
 	str := "formatter: " + disp.formatter.String() + "\n"
 
@@ -0,0 +1,336 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dynamicFileDefaultMaxOpen     = 64
+	dynamicFileDefaultIdleTimeout = 5 * time.Minute
+)
+
+// dynamicFileDispatcher writes every message to a file whose path is produced from a template
+// by the same formatter aliases as the message format, e.g. 'logs/%Ctx(Tenant)/%Date(2006-01-02).log'.
+// Writers for the paths are created on demand and kept in an LRU list: the least recently used
+// writers are closed when there are more than maxOpen of them or when they were idle for idleTimeout.
+// Idle writers are closed by a background goroutine, which waits on the dispatcher clock while
+// any writers are open.
+type dynamicFileDispatcher struct {
+	formatter     *formatter
+	pathFormatter *formatter
+	baseDir       string // Static part of the template; produced paths may not leave it
+	newWriter     func(path string) (io.Writer, error)
+	writerInfo    string    // Description of the created writers, for String
+	template      io.Writer // Writer created for the path template, describes the created writers in configs
+	maxOpen       int
+	idleTimeout   time.Duration // 0 - writers are never closed for being idle
+	clock         Clock
+	writers       map[string]*list.Element
+	lru           *list.List    // Of *dynamicFileEntry, most recently used first
+	sweeping      bool          // The idle writers closing goroutine is running
+	wake          chan struct{} // Makes the closing goroutine recheck the writers, clock and timeout
+	clockChanges  int           // Number of SetClock calls, tells the closing goroutine to wait on the new clock
+	m             sync.Mutex
+}
+
+type dynamicFileEntry struct {
+	path     string
+	writer   io.Writer
+	lastUsed time.Time
+}
+
+// NewDynamicFileDispatcher creates a dispatcher writing messages in the given format to the files
+// produced from pathTemplate. newWriter creates a writer for a path; the writer is flushed and
+// closed if it implements the corresponding interfaces.
+func NewDynamicFileDispatcher(formatter *formatter, pathTemplate string,
+	newWriter func(path string) (io.Writer, error)) (*dynamicFileDispatcher, error) {
+	if formatter == nil {
+		return nil, errors.New("formatter cannot be nil")
+	}
+	if newWriter == nil {
+		return nil, errors.New("writer constructor cannot be nil")
+	}
+	pathFormatter, err := NewFormatter(pathTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := pathTemplate
+	if i := strings.IndexByte(pathTemplate, FormatterSymbol); i != -1 {
+		baseDir = pathTemplate[:i]
+	}
+	baseDir = filepath.Dir(baseDir + "_")
+
+	return &dynamicFileDispatcher{
+		formatter:     formatter,
+		pathFormatter: pathFormatter,
+		baseDir:       baseDir,
+		newWriter:     newWriter,
+		maxOpen:       dynamicFileDefaultMaxOpen,
+		idleTimeout:   dynamicFileDefaultIdleTimeout,
+		clock:         SystemClock,
+		writers:       make(map[string]*list.Element),
+		lru:           list.New(),
+		wake:          make(chan struct{}, 1),
+	}, nil
+}
+
+// SetMaxOpen sets the maximum number of simultaneously open writers.
+func (disp *dynamicFileDispatcher) SetMaxOpen(maxOpen int) error {
+	if maxOpen <= 0 {
+		return errors.New("max open writers count must be greater than 0")
+	}
+	disp.m.Lock()
+	defer disp.m.Unlock()
+	disp.maxOpen = maxOpen
+	return nil
+}
+
+// SetIdleTimeout sets the time after which an unused writer is closed. 0 turns the idle close off.
+func (disp *dynamicFileDispatcher) SetIdleTimeout(timeout time.Duration) {
+	disp.m.Lock()
+	defer disp.m.Unlock()
+	disp.idleTimeout = timeout
+	disp.wakeSweeper()
+}
+
+// SetClock sets the clock used to track idle writers.
+func (disp *dynamicFileDispatcher) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	disp.m.Lock()
+	defer disp.m.Unlock()
+	disp.clock = clock
+	disp.clockChanges++
+	disp.wakeSweeper()
+}
+
+func (disp *dynamicFileDispatcher) Dispatch(
+	message string,
+	level LogLevel,
+	context LogContextInterface,
+	errorFunc func(err error)) {
+
+	path := filepath.Clean(disp.pathFormatter.Format(message, level, context))
+
+	disp.m.Lock()
+	defer disp.m.Unlock()
+
+	now := disp.clock.Now()
+	disp.closeIdle(now, errorFunc)
+
+	writer, err := disp.writer(path, now, errorFunc)
+	if err != nil {
+		errorFunc(err)
+		return
+	}
+	if _, err := writer.Write([]byte(disp.formatter.Format(message, level, context))); err != nil {
+		errorFunc(err)
+		return
+	}
+	if err := syncLevel(writer, level); err != nil {
+		errorFunc(err)
+	}
+}
+
+// writer returns the writer for the path, creating it if needed.
+func (disp *dynamicFileDispatcher) writer(path string, now time.Time, errorFunc func(err error)) (io.Writer, error) {
+	if elem, ok := disp.writers[path]; ok {
+		entry := elem.Value.(*dynamicFileEntry)
+		entry.lastUsed = now
+		disp.lru.MoveToFront(elem)
+		return entry.writer, nil
+	}
+
+	rel, err := filepath.Rel(disp.baseDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("dynamic file path '%s' is outside of '%s'", path, disp.baseDir)
+	}
+	writer, err := disp.newWriter(path)
+	if err != nil {
+		return nil, err
+	}
+	disp.writers[path] = disp.lru.PushFront(&dynamicFileEntry{path, writer, now})
+
+	for disp.lru.Len() > disp.maxOpen {
+		disp.closeEntry(disp.lru.Back(), errorFunc)
+	}
+	disp.startSweeper()
+	return writer, nil
+}
+
+// startSweeper starts the goroutine closing idle writers, if it is not running.
+func (disp *dynamicFileDispatcher) startSweeper() {
+	if disp.sweeping || disp.idleTimeout <= 0 || disp.lru.Len() == 0 {
+		return
+	}
+	disp.sweeping = true
+	go disp.sweepIdle()
+}
+
+func (disp *dynamicFileDispatcher) wakeSweeper() {
+	if !disp.sweeping {
+		disp.startSweeper()
+		return
+	}
+	select {
+	case disp.wake <- struct{}{}:
+	default:
+	}
+}
+
+// sweepIdle closes idle writers until all writers are closed or the idle timeout is turned off.
+func (disp *dynamicFileDispatcher) sweepIdle() {
+	var expired <-chan time.Time
+	var stopTimer func()
+	var deadline time.Time
+	var timerClockChanges int
+
+	disp.m.Lock()
+	for disp.idleTimeout > 0 && disp.lru.Len() != 0 {
+		now := disp.clock.Now()
+		disp.closeIdle(now, reportInternalError)
+		if disp.lru.Len() == 0 {
+			break
+		}
+		// Wait until the least recently used writer becomes idle. A pending wait is kept when
+		// the goroutine is woken, unless the writer becomes idle earlier or the clock changes.
+		oldest := disp.lru.Back().Value.(*dynamicFileEntry)
+		idleAt := oldest.lastUsed.Add(disp.idleTimeout)
+		if expired == nil || idleAt.Before(deadline) || timerClockChanges != disp.clockChanges {
+			if expired != nil {
+				stopTimer()
+			}
+			expired, stopTimer = newClockTimer(disp.clock, idleAt.Sub(now))
+			deadline, timerClockChanges = idleAt, disp.clockChanges
+		}
+		disp.m.Unlock()
+
+		select {
+		case <-expired:
+			expired = nil
+		case <-disp.wake:
+		}
+
+		disp.m.Lock()
+	}
+	if expired != nil {
+		stopTimer()
+	}
+	disp.sweeping = false
+	disp.m.Unlock()
+}
+
+func (disp *dynamicFileDispatcher) closeIdle(now time.Time, errorFunc func(err error)) {
+	if disp.idleTimeout <= 0 {
+		return
+	}
+	for elem := disp.lru.Back(); elem != nil; elem = disp.lru.Back() {
+		if now.Sub(elem.Value.(*dynamicFileEntry).lastUsed) < disp.idleTimeout {
+			return
+		}
+		disp.closeEntry(elem, errorFunc)
+	}
+}
+
+func (disp *dynamicFileDispatcher) closeEntry(elem *list.Element, errorFunc func(err error)) {
+	entry := disp.lru.Remove(elem).(*dynamicFileEntry)
+	delete(disp.writers, entry.path)
+	if err := closeDynamicWriter(entry.writer); err != nil {
+		errorFunc(err)
+	}
+}
+
+func closeDynamicWriter(writer io.Writer) error {
+	if flusher, ok := writer.(flusherInterface); ok {
+		flusher.Flush()
+	}
+	if closer, ok := writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// OpenPaths returns the paths of the currently open writers, most recently used first.
+func (disp *dynamicFileDispatcher) OpenPaths() []string {
+	disp.m.Lock()
+	defer disp.m.Unlock()
+	paths := make([]string, 0, disp.lru.Len())
+	for elem := disp.lru.Front(); elem != nil; elem = elem.Next() {
+		paths = append(paths, elem.Value.(*dynamicFileEntry).path)
+	}
+	return paths
+}
+
+// Flush flushes all open writers and closes the idle ones.
+func (disp *dynamicFileDispatcher) Flush() {
+	disp.m.Lock()
+	defer disp.m.Unlock()
+	for elem := disp.lru.Front(); elem != nil; elem = elem.Next() {
+		if flusher, ok := elem.Value.(*dynamicFileEntry).writer.(flusherInterface); ok {
+			flusher.Flush()
+		}
+	}
+	disp.closeIdle(disp.clock.Now(), reportInternalError)
+}
+
+// Reopen closes all writers, so that they are created again by their paths on the next messages.
+func (disp *dynamicFileDispatcher) Reopen() error {
+	return disp.Close()
+}
+
+// Close flushes and closes all open writers.
+func (disp *dynamicFileDispatcher) Close() error {
+	disp.m.Lock()
+	defer disp.m.Unlock()
+	defer disp.wakeSweeper()
+	var firstErr error
+	for elem := disp.lru.Back(); elem != nil; elem = disp.lru.Back() {
+		disp.closeEntry(elem, func(err error) {
+			if firstErr == nil {
+				firstErr = err
+			}
+		})
+	}
+	return firstErr
+}
+
+func (disp *dynamicFileDispatcher) String() string {
+	str := fmt.Sprintf("dynamicFileDispatcher (path: %s, format: %s, max open: %d, idle timeout: %v)",
+		disp.pathFormatter, disp.formatter, disp.maxOpen, disp.idleTimeout)
+	if len(disp.writerInfo) != 0 {
+		str += " -> " + disp.writerInfo
+	}
+	return str
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// closableBuffer records whether it was closed.
+type closableBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (buf *closableBuffer) Close() error {
+	buf.closed = true
+	return nil
+}
+
+func TestDynamicFileDispatcher(t *testing.T) {
+	created := make(map[string][]*closableBuffer)
+	disp, err := NewDynamicFileDispatcher(onlyMessageFormatForTest, "logs/%Ctx(Tenant)/app.log", func(path string) (io.Writer, error) {
+		buf := new(closableBuffer)
+		created[path] = append(created[path], buf)
+		return buf, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := NewManualClock(time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC))
+	disp.SetClock(clock)
+	disp.SetIdleTimeout(time.Minute)
+	if err := disp.SetMaxOpen(2); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatch := func(tenant, message string) {
+		context, err := currentContext(map[string]string{"Tenant": tenant})
+		if err != nil {
+			t.Fatal(err)
+		}
+		disp.Dispatch(message, InfoLvl, context, func(err error) { t.Error(err) })
+	}
+
+	dispatch("a", "a1")
+	dispatch("b", "b1")
+	dispatch("a", "a2")
+	expected := []string{"logs/a/app.log", "logs/b/app.log"}
+	if paths := disp.OpenPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("expected open paths %v, got %v", expected, paths)
+	}
+
+	// The least recently used writer is closed for a new one.
+	dispatch("c", "c1")
+	expected = []string{"logs/c/app.log", "logs/a/app.log"}
+	if paths := disp.OpenPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("expected open paths %v, got %v", expected, paths)
+	}
+	if !created["logs/b/app.log"][0].closed {
+		t.Error("expected the writer of b to be closed")
+	}
+
+	// Idle writers are closed on the next message or flush.
+	clock.Advance(30 * time.Second)
+	dispatch("c", "c2")
+	clock.Advance(45 * time.Second)
+	disp.Flush()
+	expected = []string{"logs/c/app.log"}
+	if paths := disp.OpenPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("expected open paths %v, got %v", expected, paths)
+	}
+
+	dispatch("a", "a3")
+	a := created["logs/a/app.log"]
+	if len(a) != 2 || a[0].String() != "a1a2" || a[1].String() != "a3" {
+		t.Errorf("unexpected writers of a: %v", a)
+	}
+	if c := created["logs/c/app.log"]; len(c) != 1 || c[0].String() != "c1c2" {
+		t.Errorf("unexpected writers of c: %v", c)
+	}
+
+	if err := disp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(disp.OpenPaths()) != 0 || !a[1].closed {
+		t.Error("expected all writers to be closed")
+	}
+}
+
+func TestDynamicFileDispatcherIdleSweep(t *testing.T) {
+	var buf *closableBuffer
+	disp, err := NewDynamicFileDispatcher(onlyMessageFormatForTest, "logs/app.log", func(path string) (io.Writer, error) {
+		buf = new(closableBuffer)
+		return buf, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := NewManualClock(time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC))
+	disp.SetClock(clock)
+	disp.SetIdleTimeout(time.Minute)
+
+	disp.Dispatch("message", InfoLvl, nil, func(err error) { t.Error(err) })
+
+	// An idle writer is closed without further messages or flushes.
+	waitFor(t, func() bool { return clock.Waiters() > 0 })
+	clock.Advance(30 * time.Second)
+	if len(disp.OpenPaths()) != 1 {
+		t.Fatal("expected the writer to be open before the idle timeout")
+	}
+	clock.Advance(30 * time.Second)
+	waitFor(t, func() bool { return len(disp.OpenPaths()) == 0 })
+
+	disp.m.Lock()
+	closed := buf.closed
+	disp.m.Unlock()
+	if !closed {
+		t.Error("expected the idle writer to be closed")
+	}
+}
+
+func TestDynamicFileDispatcherSweepTimer(t *testing.T) {
+	disp, err := NewDynamicFileDispatcher(onlyMessageFormatForTest, "logs/app.log", func(path string) (io.Writer, error) {
+		return new(closableBuffer), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := NewManualClock(time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC))
+	disp.SetClock(clock)
+	disp.SetIdleTimeout(time.Minute)
+
+	disp.Dispatch("message", InfoLvl, nil, func(err error) { t.Error(err) })
+	waitFor(t, func() bool { return clock.Waiters() > 0 })
+
+	// Waking the goroutine keeps its pending wait instead of adding new ones.
+	for i := 0; i < 5; i++ {
+		disp.SetIdleTimeout(time.Minute)
+		waitFor(t, func() bool { return len(disp.wake) == 0 })
+	}
+	if waiters := clock.Waiters(); waiters != 1 {
+		t.Errorf("expected one pending wait, got %d", waiters)
+	}
+
+	// A shorter timeout replaces the pending wait.
+	disp.SetIdleTimeout(time.Second)
+	clock.Advance(time.Second)
+	waitFor(t, func() bool { return len(disp.OpenPaths()) == 0 })
+
+	disp.Dispatch("message", InfoLvl, nil, func(err error) { t.Error(err) })
+	waitFor(t, func() bool { return clock.Waiters() > 0 })
+	if err := disp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return clock.Waiters() == 0 })
+}
+
+func TestDynamicFileDispatcherPathOutsideBase(t *testing.T) {
+	disp, err := NewDynamicFileDispatcher(onlyMessageFormatForTest, "logs/%Ctx(Tenant).log", func(path string) (io.Writer, error) {
+		t.Errorf("unexpected writer for %s", path)
+		return new(bytes.Buffer), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	context, err := currentContext(map[string]string{"Tenant": "../../etc/passwd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dispatchErr error
+	disp.Dispatch("msg", InfoLvl, context, func(err error) { dispatchErr = err })
+	if dispatchErr == nil {
+		t.Error("expected an error for a path outside of the log directory")
+	}
+}
+
+func TestDynamicRollingFileConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_dynamic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	logger, err := LoggerFromConfigAsString(`
+	<seelog type="sync">
+		<outputs formatid="msg">
+			<rollingfile dynamic="true" maxopen="1" type="size" maxsize="100" maxrolls="1"
+				filename="` + filepath.Join(dir, "%Ctx(Tenant)", "app.log") + `" />
+		</outputs>
+		<formats>
+			<format id="msg" format="%Msg%n"/>
+		</formats>
+	</seelog>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tenant := range []string{"a", "b", "a"} {
+		logger.SetContext(map[string]string{"Tenant": tenant})
+		logger.Info("for " + tenant)
+	}
+	logger.Close()
+
+	for tenant, expected := range map[string]string{"a": "for a\nfor a\n", "b": "for b\n"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, tenant, "app.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("expected %q for tenant %s, got %q", expected, tenant, string(data))
+		}
+	}
+}
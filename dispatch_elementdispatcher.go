@@ -0,0 +1,127 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"fmt"
+	"io"
+)
+
+// ElementDispatcher handles the messages reaching a config element registered with
+// RegisterDispatcherElement. If it implements Flush() or io.Closer, they are called
+// when the logger is flushed or closed, after the child outputs are.
+type ElementDispatcher interface {
+	// Dispatch is called for every message reaching the element. It passes the messages
+	// that should reach the child outputs, possibly changed, to outputs. An error returned
+	// is reported like other seelog internal errors.
+	Dispatch(message string, level LogLevel, context LogContextInterface, outputs ElementOutputs) error
+}
+
+// ElementOutputs are the child outputs of a registered dispatcher element, in the config order.
+type ElementOutputs struct {
+	disp      *elementDispatcher
+	errorFunc func(err error)
+}
+
+// Len returns the number of child outputs.
+func (outputs ElementOutputs) Len() int {
+	return len(outputs.disp.receivers)
+}
+
+// Dispatch passes a message to all the child outputs.
+func (outputs ElementOutputs) Dispatch(message string, level LogLevel, context LogContextInterface) {
+	outputs.disp.dispatcher.Dispatch(message, level, context, outputs.errorFunc)
+}
+
+// DispatchTo passes a message to the child output with index i.
+func (outputs ElementOutputs) DispatchTo(i int, message string, level LogLevel, context LogContextInterface) {
+	switch receiver := outputs.disp.receivers[i].(type) {
+	case *formattedWriter:
+		if err := receiver.Write(message, level, context); err != nil {
+			outputs.errorFunc(err)
+		}
+	case dispatcherInterface:
+		receiver.Dispatch(message, level, context, outputs.errorFunc)
+	}
+}
+
+// elementDispatcher is the dispatcher of a registered dispatcher element.
+type elementDispatcher struct {
+	*dispatcher
+	receivers []interface{}
+	impl      ElementDispatcher
+	node      *xmlNode // Config element, kept to describe the dispatcher in configs
+}
+
+func newElementDispatcher(formatter *formatter, receivers []interface{}, impl ElementDispatcher, node *xmlNode) (*elementDispatcher, error) {
+	disp, err := createDispatcher(formatter, receivers)
+	if err != nil {
+		return nil, err
+	}
+	// Receivers are kept in the config order, with plain writers formatted as the dispatcher does.
+	ordered := make([]interface{}, 0, len(receivers))
+	writers := disp.Writers()
+	for _, receiver := range receivers {
+		if _, ok := receiver.(dispatcherInterface); ok {
+			ordered = append(ordered, receiver)
+			continue
+		}
+		ordered = append(ordered, writers[0])
+		writers = writers[1:]
+	}
+	return &elementDispatcher{disp, ordered, impl, node}, nil
+}
+
+func (disp *elementDispatcher) Dispatch(
+	message string,
+	level LogLevel,
+	context LogContextInterface,
+	errorFunc func(err error)) {
+
+	err := disp.impl.Dispatch(message, level, context, ElementOutputs{disp, errorFunc})
+	if err != nil {
+		errorFunc(fmt.Errorf("'%s' element: %v", disp.node.name, err))
+	}
+}
+
+func (disp *elementDispatcher) Flush() {
+	disp.dispatcher.Flush()
+	if flusher, ok := disp.impl.(flusherInterface); ok {
+		flusher.Flush()
+	}
+}
+
+func (disp *elementDispatcher) Close() error {
+	err := disp.dispatcher.Close()
+	if closer, ok := disp.impl.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (disp *elementDispatcher) String() string {
+	return fmt.Sprintf("%s element dispatcher ->\n%s", disp.node.name, disp.dispatcher)
+}
@@ -25,14 +25,208 @@
 package seelog
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 )
 
+// FilterMode defines what a filterDispatcher does with messages that match its criteria.
+type FilterMode uint8
+
+const (
+	// FilterInclude passes only messages that match the filter criteria.
+	FilterInclude FilterMode = iota
+	// FilterExclude passes only messages that do not match the filter criteria.
+	FilterExclude
+)
+
+var filterModeToStringMap = map[FilterMode]string{
+	FilterInclude: "include",
+	FilterExclude: "exclude",
+}
+
+func (mode FilterMode) String() string {
+	return filterModeToStringMap[mode]
+}
+
+func filterModeFromString(str string) (FilterMode, bool) {
+	for mode, modeStr := range filterModeToStringMap {
+		if modeStr == str {
+			return mode, true
+		}
+	}
+	return 0, false
+}
+
+// FilterRule is a message criterion checked by a filterDispatcher in addition to log levels.
+type FilterRule interface {
+	// Matches returns true if the message satisfies the rule.
+	Matches(message string, level LogLevel, context LogContextInterface) bool
+	String() string
+}
+
+type containsRule struct {
+	substr string
+}
+
+// NewMessageContainsRule creates a rule matching messages which contain substr.
+func NewMessageContainsRule(substr string) FilterRule {
+	return &containsRule{substr}
+}
+
+func (rule *containsRule) Matches(message string, level LogLevel, context LogContextInterface) bool {
+	return strings.Contains(message, rule.substr)
+}
+
+func (rule *containsRule) String() string {
+	return fmt.Sprintf("contains %q", rule.substr)
+}
+
+type regexpRule struct {
+	expr *regexp.Regexp
+}
+
+// NewMessageRegexpRule creates a rule matching messages against a regular expression.
+func NewMessageRegexpRule(expr string) (FilterRule, error) {
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &regexpRule{compiled}, nil
+}
+
+func (rule *regexpRule) Matches(message string, level LogLevel, context LogContextInterface) bool {
+	return rule.expr.MatchString(message)
+}
+
+func (rule *regexpRule) String() string {
+	return fmt.Sprintf("regexp %q", rule.expr)
+}
+
+type callSiteRule struct {
+	pattern      string
+	patternParts []string
+	file         bool
+}
+
+// NewFuncPatternRule creates a rule matching the caller function name against a pattern
+// with asterisks, the same way as in exceptions.
+func NewFuncPatternRule(pattern string) (FilterRule, error) {
+	return newCallSiteRule(pattern, false)
+}
+
+// NewFilePatternRule creates a rule matching the caller file path against a pattern
+// with asterisks, the same way as in exceptions.
+func NewFilePatternRule(pattern string) (FilterRule, error) {
+	return newCallSiteRule(pattern, true)
+}
+
+func newCallSiteRule(pattern string, file bool) (*callSiteRule, error) {
+	if len(pattern) == 0 {
+		return nil, errors.New("pattern can not be empty")
+	}
+	return &callSiteRule{pattern, splitPattern(pattern), file}, nil
+}
+
+func (rule *callSiteRule) Matches(message string, level LogLevel, context LogContextInterface) bool {
+	if context == nil || !context.IsValid() {
+		return false
+	}
+	if rule.file {
+		return stringMatchesPattern(rule.patternParts, context.FullPath())
+	}
+	return stringMatchesPattern(rule.patternParts, context.Func())
+}
+
+func (rule *callSiteRule) String() string {
+	if rule.file {
+		return fmt.Sprintf("file %q", rule.pattern)
+	}
+	return fmt.Sprintf("func %q", rule.pattern)
+}
+
+type contextFieldRule struct {
+	key        string
+	value      string
+	valueParts []string
+}
+
+// NewContextFieldRule creates a rule matching messages which custom context has the
+// field key with a value matching a pattern with asterisks. Custom context may be a map
+// with string keys or a struct (or a pointer to a struct) with an exported field named key.
+func NewContextFieldRule(key, value string) (FilterRule, error) {
+	if len(key) == 0 {
+		return nil, errors.New("context field name can not be empty")
+	}
+	return &contextFieldRule{key, value, splitPattern(value)}, nil
+}
+
+func (rule *contextFieldRule) Matches(message string, level LogLevel, context LogContextInterface) bool {
+	if context == nil {
+		return false
+	}
+	field, ok := customContextField(context.CustomContext(), rule.key)
+	if !ok {
+		return false
+	}
+	return stringMatchesPattern(rule.valueParts, fmt.Sprint(field))
+}
+
+func (rule *contextFieldRule) String() string {
+	return fmt.Sprintf("context %s=%q", rule.key, rule.value)
+}
+
+// customContextField returns the field of a map or struct custom context.
+func customContextField(custom interface{}, key string) (interface{}, bool) {
+	switch ctx := custom.(type) {
+	case nil:
+		return nil, false
+	case map[string]string:
+		value, ok := ctx[key]
+		return value, ok
+	case map[string]interface{}:
+		value, ok := ctx[key]
+		return value, ok
+	}
+
+	value := reflect.ValueOf(custom)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, false
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		field := value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+		if !field.IsValid() {
+			return nil, false
+		}
+		return field.Interface(), true
+	case reflect.Struct:
+		field, ok := value.Type().FieldByName(key)
+		if !ok || len(field.PkgPath) != 0 {
+			return nil, false
+		}
+		return value.FieldByIndex(field.Index).Interface(), true
+	}
+	return nil, false
+}
+
 // A filterDispatcher writes the given message to underlying receivers only if message log level
-// is in the allowed list.
+// is in the allowed list and the message matches all the filter rules. In exclude mode, messages
+// are written only if they do not match.
 type filterDispatcher struct {
 	*dispatcher
 	allowList map[LogLevel]bool
+	anyLevel  bool
+	rules     []FilterRule
+	mode      FilterMode
 }
 
 // NewFilterDispatcher creates a new filterDispatcher using a list of allowed levels.
@@ -47,7 +241,42 @@ func NewFilterDispatcher(formatter *formatter, receivers []interface{}, allowLis
 		allows[allowLevel] = true
 	}
 
-	return &filterDispatcher{disp, allows}, nil
+	return &filterDispatcher{disp, allows, false, nil, FilterInclude}, nil
+}
+
+// NewRuleFilterDispatcher creates a new filterDispatcher which checks the message against
+// the rules. If levels is nil, messages of any level match, otherwise only messages
+// of the specified levels do.
+func NewRuleFilterDispatcher(formatter *formatter, receivers []interface{}, mode FilterMode,
+	levels []LogLevel, rules ...FilterRule) (*filterDispatcher, error) {
+	if _, ok := filterModeToStringMap[mode]; !ok {
+		return nil, fmt.Errorf("invalid filter mode: %d", mode)
+	}
+	filter, err := NewFilterDispatcher(formatter, receivers, levels...)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule == nil {
+			return nil, errors.New("filter rule can not be nil")
+		}
+	}
+	filter.anyLevel = levels == nil
+	filter.rules = rules
+	filter.mode = mode
+	return filter, nil
+}
+
+func (filter *filterDispatcher) matches(message string, level LogLevel, context LogContextInterface) bool {
+	if !filter.anyLevel && !filter.allowList[level] {
+		return false
+	}
+	for _, rule := range filter.rules {
+		if !rule.Matches(message, level, context) {
+			return false
+		}
+	}
+	return true
 }
 
 func (filter *filterDispatcher) Dispatch(
@@ -55,12 +284,19 @@ func (filter *filterDispatcher) Dispatch(
 	level LogLevel,
 	context LogContextInterface,
 	errorFunc func(err error)) {
-	isAllowed, ok := filter.allowList[level]
-	if ok && isAllowed {
+	if filter.matches(message, level, context) == (filter.mode == FilterInclude) {
 		filter.dispatcher.Dispatch(message, level, context, errorFunc)
 	}
 }
 
 func (filter *filterDispatcher) String() string {
-	return fmt.Sprintf("filterDispatcher ->\n%s", filter.dispatcher)
+	if len(filter.rules) == 0 && filter.mode == FilterInclude && !filter.anyLevel {
+		return fmt.Sprintf("filterDispatcher ->\n%s", filter.dispatcher)
+	}
+	rules := make([]string, len(filter.rules))
+	for i, rule := range filter.rules {
+		rules[i] = rule.String()
+	}
+	return fmt.Sprintf("filterDispatcher (mode: %s, rules: [%s]) ->\n%s",
+		filter.mode, strings.Join(rules, ", "), filter.dispatcher)
 }
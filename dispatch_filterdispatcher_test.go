@@ -65,3 +65,73 @@ func TestfilterDispatcher_Deny(t *testing.T) {
 	bytes := []byte("Hello")
 	filter.Dispatch(string(bytes), TraceLvl, context, func(err error) {})
 }
+
+func TestFilterDispatcherRules(t *testing.T) {
+	funcRule, _ := NewFuncPatternRule("*seelog.TestFilterDispatcherRules")
+	otherFuncRule, _ := NewFuncPatternRule("*myapp/auth.*")
+	regexpRule, _ := NewMessageRegexpRule("^audit:")
+	contextRule, _ := NewContextFieldRule("User", "adm*")
+
+	type userContext struct {
+		User string
+	}
+
+	tests := []struct {
+		name    string
+		mode    FilterMode
+		levels  []LogLevel
+		rules   []FilterRule
+		message string
+		custom  interface{}
+		pass    bool
+	}{
+		{"contains", FilterInclude, nil, []FilterRule{NewMessageContainsRule("audit")}, "an audit event", nil, true},
+		{"contains mismatch", FilterInclude, nil, []FilterRule{NewMessageContainsRule("audit")}, "event", nil, false},
+		{"regexp", FilterInclude, nil, []FilterRule{regexpRule}, "audit: login", nil, true},
+		{"regexp mismatch", FilterInclude, nil, []FilterRule{regexpRule}, "no audit: login", nil, false},
+		{"level mismatch", FilterInclude, []LogLevel{ErrorLvl}, []FilterRule{regexpRule}, "audit: login", nil, false},
+		{"func", FilterInclude, nil, []FilterRule{funcRule}, "msg", nil, true},
+		{"func mismatch", FilterInclude, nil, []FilterRule{otherFuncRule}, "msg", nil, false},
+		{"context map", FilterInclude, nil, []FilterRule{contextRule}, "msg", map[string]string{"User": "admin"}, true},
+		{"context struct", FilterInclude, nil, []FilterRule{contextRule}, "msg", &userContext{"admin"}, true},
+		{"context mismatch", FilterInclude, nil, []FilterRule{contextRule}, "msg", map[string]interface{}{"User": "guest"}, false},
+		{"context missing", FilterInclude, nil, []FilterRule{contextRule}, "msg", nil, false},
+		{"exclude", FilterExclude, nil, []FilterRule{regexpRule}, "audit: login", nil, false},
+		{"exclude mismatch", FilterExclude, nil, []FilterRule{regexpRule}, "login", nil, true},
+		{"exclude levels", FilterExclude, []LogLevel{TraceLvl}, nil, "msg", nil, false},
+	}
+
+	for _, test := range tests {
+		writer, _ := newBytesVerifier(t)
+		filter, err := NewRuleFilterDispatcher(onlyMessageFormatForTest, []interface{}{writer}, test.mode, test.levels, test.rules...)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		context, err := currentContext(test.custom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		context = &funcContext{context, "github.com/cihub/seelog.TestFilterDispatcherRules"}
+
+		if test.pass {
+			writer.ExpectBytes([]byte(test.message))
+		}
+		filter.Dispatch(test.message, TraceLvl, context, func(err error) {})
+		if test.pass {
+			writer.MustNotExpect()
+		}
+	}
+}
+
+// funcContext overrides the caller function name, which may be reported incorrectly
+// for inlined callers.
+type funcContext struct {
+	LogContextInterface
+	funcName string
+}
+
+func (context *funcContext) Func() string {
+	return context.funcName
+}
@@ -0,0 +1,388 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package seelog
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// RedactMode defines how a redactDispatcher replaces the secrets it finds.
+type RedactMode uint8
+
+const (
+	// RedactMask replaces a secret with a fixed mask that hides both contents and length.
+	RedactMask RedactMode = iota
+	// RedactHash replaces a secret with a short HMAC-SHA256 digest, so that equal secrets
+	// may still be correlated in logs. The digest is keyed, so that low-entropy secrets
+	// like card numbers or short passwords cannot be recovered from logs by brute force.
+	// Without a key set with SetHashKey (the 'hashkey' attribute in configs), a random
+	// key is generated per process, and digests may only be correlated within a process.
+	RedactHash
+)
+
+const (
+	redactMaskString = "********"
+	redactHashPrefix = "hmac:"
+	redactHashLength = 12
+	// Name of the regexp group which limits the replacement to a part of the match.
+	redactSecretGroup = "secret"
+)
+
+var redactModeToStringMap = map[RedactMode]string{
+	RedactMask: "mask",
+	RedactHash: "hash",
+}
+
+func (mode RedactMode) String() string {
+	return redactModeToStringMap[mode]
+}
+
+func redactModeFromString(str string) (RedactMode, bool) {
+	for mode, modeStr := range redactModeToStringMap {
+		if modeStr == str {
+			return mode, true
+		}
+	}
+	return 0, false
+}
+
+// redactProcessKey is the hash key of the rules without a key set. It is nil if it could
+// not be generated, and then secrets are masked instead of hashed.
+var redactProcessKey = func() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		reportInternalError(fmt.Errorf("cannot generate redact hash key: %s", err))
+		return nil
+	}
+	return key
+}()
+
+// redactPresetValidators check the matches of presets which patterns alone would overmatch.
+var redactPresetValidators = map[string]func(match string) bool{
+	"creditcard": isLuhnValid,
+}
+
+// redactPresets are the named rules that may be used instead of custom patterns.
+var redactPresets = map[string]string{
+	"creditcard": `\b(?:\d[ -]?){12,18}\d\b`,
+	"bearer":     `(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`,
+	"awskey":     `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	"email":      `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	"password":   `(?i)\b(?:password|passwd|pwd)\s*[=:]\s*(?P<secret>[^\s&,;]+)`,
+}
+
+type redactRule struct {
+	name     string
+	expr     *regexp.Regexp
+	mode     RedactMode
+	group    int
+	validate func(match string) bool // Nil, or checks that a match is a secret
+	hashKey  []byte
+}
+
+// NewRedactRule creates a redaction rule from a regular expression. If the expression
+// has a group named 'secret', only that group is replaced, otherwise the whole match is.
+func NewRedactRule(pattern string, mode RedactMode) (*redactRule, error) {
+	if len(pattern) == 0 {
+		return nil, errors.New("redact pattern can not be empty")
+	}
+	return newRedactRule(pattern, pattern, mode)
+}
+
+// NewRedactPreset creates one of the predefined redaction rules: 'creditcard', 'bearer',
+// 'awskey', 'email' or 'password'. The 'creditcard' rule only replaces numbers passing
+// the Luhn check.
+func NewRedactPreset(name string, mode RedactMode) (*redactRule, error) {
+	pattern, ok := redactPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown redact preset: '%s'", name)
+	}
+	rule, err := newRedactRule(name, pattern, mode)
+	if err != nil {
+		return nil, err
+	}
+	rule.validate = redactPresetValidators[name]
+	return rule, nil
+}
+
+// SetHashKey sets the key of the digests replacing secrets in RedactHash mode. Rules with
+// the same key replace equal secrets with equal digests.
+func (rule *redactRule) SetHashKey(key []byte) {
+	rule.hashKey = key
+}
+
+func newRedactRule(name, pattern string, mode RedactMode) (*redactRule, error) {
+	if _, ok := redactModeToStringMap[mode]; !ok {
+		return nil, fmt.Errorf("invalid redact mode: %d", mode)
+	}
+	expr, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	group := 0
+	for i, groupName := range expr.SubexpNames() {
+		if groupName == redactSecretGroup {
+			group = i
+			break
+		}
+	}
+	return &redactRule{name: name, expr: expr, mode: mode, group: group}, nil
+}
+
+func (rule *redactRule) replacement(secret string) string {
+	key := rule.hashKey
+	if key == nil {
+		key = redactProcessKey
+	}
+	if rule.mode == RedactHash && key != nil {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(secret))
+		return redactHashPrefix + hex.EncodeToString(mac.Sum(nil))[:redactHashLength]
+	}
+	return redactMaskString
+}
+
+func (rule *redactRule) apply(str string) string {
+	matches := rule.expr.FindAllStringSubmatchIndex(str, -1)
+	if matches == nil {
+		return str
+	}
+	var result strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[2*rule.group], match[2*rule.group+1]
+		if start < 0 || (rule.validate != nil && !rule.validate(str[match[0]:match[1]])) {
+			continue
+		}
+		result.WriteString(str[last:start])
+		result.WriteString(rule.replacement(str[start:end]))
+		last = end
+	}
+	result.WriteString(str[last:])
+	return result.String()
+}
+
+// isLuhnValid returns true if the digits of the string pass the Luhn check of card numbers.
+func isLuhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if digits%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return digits != 0 && sum%10 == 0
+}
+
+func (rule *redactRule) String() string {
+	return fmt.Sprintf("%s:%s", rule.name, rule.mode)
+}
+
+// A redactDispatcher replaces secrets in messages and in string fields of custom
+// context maps before passing them to the underlying receivers. As messages are
+// redacted before formatting, no receiver of the subtree ever gets the original secrets.
+type redactDispatcher struct {
+	*dispatcher
+	rules []*redactRule
+}
+
+// NewRedactDispatcher creates a new redactDispatcher applying the rules in the given order.
+func NewRedactDispatcher(formatter *formatter, receivers []interface{}, rules ...*redactRule) (*redactDispatcher, error) {
+	if len(rules) == 0 {
+		return nil, errors.New("redact rules can not be empty")
+	}
+	for _, rule := range rules {
+		if rule == nil {
+			return nil, errors.New("redact rule can not be nil")
+		}
+	}
+	disp, err := createDispatcher(formatter, receivers)
+	if err != nil {
+		return nil, err
+	}
+	return &redactDispatcher{disp, rules}, nil
+}
+
+func (redact *redactDispatcher) redact(str string) string {
+	for _, rule := range redact.rules {
+		str = rule.apply(str)
+	}
+	return str
+}
+
+// maxRedactDepth limits the nesting of custom contexts redacted field by field. Deeper values
+// are masked entirely.
+const maxRedactDepth = 16
+
+var (
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// redactCustom returns a redacted copy of a custom context. Strings are redacted, maps, slices
+// and structs are copied with redacted values: structs become maps of their exported fields, so
+// that context filters and formatters still find the fields. Values implementing fmt.Stringer or
+// error and values that cannot be copied are replaced with their redacted fmt.Sprint form, so
+// that no unredacted data reaches the receivers. Structs without exported fields are masked
+// entirely, as their fmt.Sprint form shows the unexported fields.
+func (redact *redactDispatcher) redactCustom(custom interface{}) interface{} {
+	switch fields := custom.(type) {
+	case nil:
+		return nil
+	case string:
+		return redact.redact(fields)
+	case map[string]string:
+		redacted := make(map[string]string, len(fields))
+		for key, value := range fields {
+			redacted[key] = redact.redact(value)
+		}
+		return redacted
+	}
+	return redact.redactValue(reflect.ValueOf(custom), 0)
+}
+
+func (redact *redactDispatcher) redactValue(value reflect.Value, depth int) interface{} {
+	if !value.IsValid() {
+		return nil
+	}
+	if depth > maxRedactDepth {
+		return redactedValueMask(value)
+	}
+	if value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		if value.Type().Implements(stringerType) || value.Type().Implements(errorType) {
+			return redact.redact(fmt.Sprint(value.Interface()))
+		}
+		return redact.redactValue(value.Elem(), depth+1)
+	}
+	if value.CanInterface() && (value.Type().Implements(stringerType) || value.Type().Implements(errorType)) {
+		return redact.redact(fmt.Sprint(value.Interface()))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return redact.redact(value.String())
+	case reflect.Bool:
+		return value.Interface()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		// Numbers may hold secrets too, e.g. card numbers.
+		str := fmt.Sprint(value.Interface())
+		if redacted := redact.redact(str); redacted != str {
+			return redacted
+		}
+		return value.Interface()
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			break
+		}
+		redacted := make(map[string]interface{}, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			redacted[iter.Key().String()] = redact.redactValue(iter.Value(), depth+1)
+		}
+		return redacted
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			bytes := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(bytes), value)
+			return redact.redact(string(bytes))
+		}
+		redacted := make([]interface{}, value.Len())
+		for i := range redacted {
+			redacted[i] = redact.redactValue(value.Index(i), depth+1)
+		}
+		return redacted
+	case reflect.Struct:
+		redacted := make(map[string]interface{}, value.NumField())
+		for i := 0; i < value.NumField(); i++ {
+			if field := value.Type().Field(i); len(field.PkgPath) == 0 {
+				redacted[field.Name] = redact.redactValue(value.Field(i), depth+1)
+			}
+		}
+		if len(redacted) != 0 {
+			return redacted
+		}
+		return redactedValueMask(value)
+	}
+	if !value.CanInterface() {
+		return nil
+	}
+	return redact.redact(fmt.Sprint(value.Interface()))
+}
+
+// redactedValueMask replaces a value which cannot be redacted field by field.
+func redactedValueMask(value reflect.Value) string {
+	return fmt.Sprintf("[REDACTED %s]", value.Type())
+}
+
+func (redact *redactDispatcher) Dispatch(
+	message string,
+	level LogLevel,
+	context LogContextInterface,
+	errorFunc func(err error)) {
+	if context != nil && context.CustomContext() != nil {
+		context = &redactedContext{context, redact.redactCustom(context.CustomContext())}
+	}
+	redact.dispatcher.Dispatch(redact.redact(message), level, context, errorFunc)
+}
+
+func (redact *redactDispatcher) String() string {
+	rules := make([]string, len(redact.rules))
+	for i, rule := range redact.rules {
+		rules[i] = rule.String()
+	}
+	return fmt.Sprintf("redactDispatcher (rules: [%s]) ->\n%s", strings.Join(rules, ", "), redact.dispatcher)
+}
+
+// redactedContext replaces the custom context of a log context with its redacted copy.
+type redactedContext struct {
+	LogContextInterface
+	custom interface{}
+}
+
+func (context *redactedContext) CustomContext() interface{} {
+	return context.custom
+}
@@ -132,6 +132,24 @@ logs to console, all.log, and errors.log depending on the log level. Its output
 use log level 'debug' and higher (minlevel is set) for all files with names that don't start with 'test'. For files starting with 'test'
 this logger prohibits all levels below 'error'.
 
+Environment variables
+
+The following environment variables override the configs loaded by the LoggerFrom*Config* funcs,
+as well as the config of the Default logger, so that the verbosity or the format of a deployed
+application may be changed without replacing its config file:
+    SEELOG_MINLEVEL    minimal level, e.g. 'debug'; replaces 'minlevel' and drops 'levels'
+    SEELOG_MAXLEVEL    maximal level; replaces 'maxlevel' and drops 'levels'
+    SEELOG_LEVELS      list of levels, e.g. 'info,error'; replaces all the level attributes
+    SEELOG_EXCEPTIONS  comma-separated exceptions checked before the config ones, as
+                       'funcpattern:minlevel', '@filepattern:minlevel' or
+                       'funcpattern@filepattern:minlevel', e.g. 'main.*:trace,@*db.go:debug'
+    SEELOG_FORMAT      format of all the outputs: a format id of the config, a predefined
+                       format like 'std:json' or, if it contains '%', a format string
+    SEELOG_PROFILE     name of the config profile to use
+The overrides are checked like the rest of the config and are listed by ConfigInspector.Explain.
+Set CfgParseParams.IgnoreEnvOverrides to load a config exactly as it is written. The Disabled
+logger is never affected.
+
 Configuration using code
 
 Although configuration using code is not recommended, it is sometimes needed and it is possible to do with seelog. Basically, what
@@ -108,6 +108,7 @@ var formatterFuncsParameterized = map[string]FormatterFuncCreator{
 	"Date":    createDateTimeFormatterFunc,
 	"UTCDate": createUTCDateTimeFormatterFunc,
 	"EscM":    createANSIEscapeFunc,
+	"Ctx":     createCustomContextFieldFunc,
 }
 
 func errorAliasReserved(name string) error {
@@ -146,6 +147,7 @@ type formatter struct {
 	fmtStringOriginal string
 	fmtString         string
 	formatterFuncs    []FormatterFunc
+	id                string // Id of the format in the config it was declared in, if any
 }
 
 // NewFormatter creates a new formatter using a format string
@@ -455,6 +457,16 @@ func createUTCDateTimeFormatterFunc(dateTimeFormat string) FormatterFunc {
 	}
 }
 
+// createCustomContextFieldFunc returns the field of a map or struct custom context, or an empty string.
+func createCustomContextFieldFunc(key string) FormatterFunc {
+	return func(message string, level LogLevel, context LogContextInterface) interface{} {
+		if value, ok := customContextField(context.CustomContext(), key); ok {
+			return value
+		}
+		return ""
+	}
+}
+
 func createANSIEscapeFunc(escapeCodeString string) FormatterFunc {
 	return func(message string, level LogLevel, context LogContextInterface) interface{} {
 		if len(escapeCodeString) == 0 {
@@ -194,6 +194,20 @@ func TestDateParameterizedFormat(t *testing.T) {
 	}
 }
 
+func TestCustomContextFieldFormat(t *testing.T) {
+	form, err := NewFormatter("%Ctx(Tenant)/%Ctx(Missing)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	context, err := currentContext(struct{ Tenant string }{"acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := form.Format("", TraceLvl, context); msg != "acme/" {
+		t.Errorf("incorrect message: %q. Expected %q", msg, "acme/")
+	}
+}
+
 func createTestFormatter(format string) FormatterFunc {
 	return func(message string, level LogLevel, context LogContextInterface) interface{} {
 		return "TEST " + context.Func() + " TEST"
@@ -0,0 +1,93 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var errFileLockingNotSupported = errors.New("file locking is not supported on this platform")
+
+// processLock is an advisory lock of a log file shared by all processes writing to it.
+// It locks a separate hidden file next to the log file, because the log file itself
+// is renamed on rolls. The lock is also exclusive between goroutines of one process.
+type processLock struct {
+	path string
+	file *os.File
+	m    sync.Mutex
+}
+
+// newProcessLock returns a lock for the log file at logPath.
+func newProcessLock(logPath string) (*processLock, error) {
+	if !fileLockingSupported {
+		return nil, errFileLockingNotSupported
+	}
+	dir, name := filepath.Split(logPath)
+	return &processLock{path: filepath.Join(dir, "."+name+".lock")}, nil
+}
+
+// Lock blocks until the lock is acquired.
+func (lock *processLock) Lock() error {
+	lock.m.Lock()
+	if lock.file == nil {
+		if err := os.MkdirAll(filepath.Dir(lock.path), defaultDirectoryPermissions); err != nil {
+			lock.m.Unlock()
+			return err
+		}
+		file, err := os.OpenFile(lock.path, os.O_RDWR|os.O_CREATE, defaultFilePermissions)
+		if err != nil {
+			lock.m.Unlock()
+			return err
+		}
+		lock.file = file
+	}
+	if err := lockFile(lock.file); err != nil {
+		lock.m.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unlock releases the lock acquired by Lock.
+func (lock *processLock) Unlock() {
+	if err := unlockFile(lock.file); err != nil {
+		reportInternalError(err)
+	}
+	lock.m.Unlock()
+}
+
+// Close closes the lock file. The lock may be used again after that.
+func (lock *processLock) Close() error {
+	lock.m.Lock()
+	defer lock.m.Unlock()
+	if lock.file == nil {
+		return nil
+	}
+	err := lock.file.Close()
+	lock.file = nil
+	return err
+}
@@ -318,3 +318,17 @@ func tryRemoveFile(filePath string) (err error) {
 	}
 	return
 }
+
+// fileReplaced returns true if the file at path is not the opened file f any more:
+// it was removed, moved away or replaced with another file.
+func fileReplaced(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(opened, current)
+}
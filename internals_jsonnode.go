@@ -0,0 +1,159 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// unmarshalJSONConfig reads a JSON config into the same node tree as an XML config, so that
+// both formats are parsed by the same element constructors. Each element is an object member:
+//   - members with string, number or boolean values are the element attributes;
+//   - members with object values are child elements named by the member;
+//   - members with arrays of objects are several child elements with the same name.
+// The root object has a single member, the root element:
+//   {"seelog": {"type": "sync", "outputs": {"file": [{"path": "a.log"}, {"path": "b.log"}]}}}
+// Nodes remember their JSON paths (e.g. '$.seelog.outputs.file[1]') to report errors.
+func unmarshalJSONConfig(reader io.Reader) (*xmlNode, error) {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	if err := expectJSONDelim(decoder, '{', "$"); err != nil {
+		return nil, err
+	}
+	if !decoder.More() {
+		return nil, newJSONConfigError("$", "config has no root element")
+	}
+	name, err := jsonMemberName(decoder, "$")
+	if err != nil {
+		return nil, err
+	}
+	path := "$." + name
+	if err := expectJSONDelim(decoder, '{', path); err != nil {
+		return nil, err
+	}
+	config, err := unmarshalJSONNode(decoder, name, path)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, newJSONConfigError("$", "config contains more than one root element")
+	}
+	if err := expectJSONDelim(decoder, '}', "$"); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, newJSONConfigError("$", "unexpected data after the root object")
+	}
+	return config, nil
+}
+
+// unmarshalJSONNode reads the members of an element object, which opening brace is already read.
+func unmarshalJSONNode(decoder *json.Decoder, name, path string) (*xmlNode, error) {
+	node := newNode()
+	node.name = name
+	node.location = path
+
+	for decoder.More() {
+		key, err := jsonMemberName(decoder, path)
+		if err != nil {
+			return nil, err
+		}
+		memberPath := path + "." + key
+
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, newJSONConfigError(memberPath, err.Error())
+		}
+		switch value := tok.(type) {
+		case json.Delim:
+			if value == '{' {
+				child, err := unmarshalJSONNode(decoder, key, memberPath)
+				if err != nil {
+					return nil, err
+				}
+				node.add(child)
+				continue
+			}
+			if value != '[' {
+				return nil, newJSONConfigError(memberPath, "unexpected "+value.String())
+			}
+			for i := 0; decoder.More(); i++ {
+				itemPath := fmt.Sprintf("%s[%d]", memberPath, i)
+				if err := expectJSONDelim(decoder, '{', itemPath); err != nil {
+					return nil, err
+				}
+				child, err := unmarshalJSONNode(decoder, key, itemPath)
+				if err != nil {
+					return nil, err
+				}
+				node.add(child)
+			}
+			if err := expectJSONDelim(decoder, ']', memberPath); err != nil {
+				return nil, err
+			}
+		case string, json.Number, bool:
+			if _, ok := node.attributes[key]; ok {
+				return nil, newJSONConfigError(memberPath, "duplicated attribute")
+			}
+			node.attributes[key] = fmt.Sprint(value)
+		default:
+			return nil, newJSONConfigError(memberPath, "value must be a string, number, boolean, object or array of objects")
+		}
+	}
+
+	if err := expectJSONDelim(decoder, '}', path); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func jsonMemberName(decoder *json.Decoder, path string) (string, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return "", newJSONConfigError(path, err.Error())
+	}
+	name, ok := tok.(string)
+	if !ok {
+		return "", newJSONConfigError(path, fmt.Sprintf("unexpected %v", tok))
+	}
+	return name, nil
+}
+
+func expectJSONDelim(decoder *json.Decoder, delim json.Delim, path string) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return newJSONConfigError(path, err.Error())
+	}
+	if tok != delim {
+		return newJSONConfigError(path, fmt.Sprintf("expected '%v', got %v", delim, tok))
+	}
+	return nil
+}
+
+func newJSONConfigError(path, msg string) error {
+	return &ConfigError{Path: path, Err: fmt.Errorf("json config: %s", msg)}
+}
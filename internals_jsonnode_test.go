@@ -0,0 +1,98 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package seelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestJSONNode(name, location string, attributes map[string]string, children ...*xmlNode) *xmlNode {
+	node := newNode()
+	node.name = name
+	node.location = location
+	for attr, value := range attributes {
+		node.attributes[attr] = value
+	}
+	node.children = append(node.children, children...)
+	return node
+}
+
+func TestJSONNode(t *testing.T) {
+	tests := []struct {
+		testName string
+		input    string
+		expected *xmlNode
+	}{
+		{"Simple", `{"a": {}}`, newTestJSONNode("a", "$.a", nil)},
+		{"Attributes", `{"a": {"s": "str", "n": 1.5, "b": true}}`,
+			newTestJSONNode("a", "$.a", map[string]string{"s": "str", "n": "1.5", "b": "true"})},
+		{"Children", `{"a": {"x": "1", "b": {"y": "2"}, "c": [{"z": "3"}, {}], "d": {}}}`,
+			newTestJSONNode("a", "$.a", map[string]string{"x": "1"},
+				newTestJSONNode("b", "$.a.b", map[string]string{"y": "2"}),
+				newTestJSONNode("c", "$.a.c[0]", map[string]string{"z": "3"}),
+				newTestJSONNode("c", "$.a.c[1]", nil),
+				newTestJSONNode("d", "$.a.d", nil))},
+	}
+	for _, test := range tests {
+		node, err := unmarshalJSONConfig(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.testName, err)
+			continue
+		}
+		if !reflect.DeepEqual(node, test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.testName, test.expected, node)
+		}
+	}
+}
+
+func TestJSONNodeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		location string
+	}{
+		{``, "$"},
+		{`[]`, "$"},
+		{`{}`, "$"},
+		{`{"a": {}, "b": {}}`, "$"},
+		{`{"a": {}} {}`, "$"},
+		{`{"a": "str"}`, "$.a"},
+		{`{"a": {"b": null}}`, "$.a.b"},
+		{`{"a": {"b": [{}, "str"]}}`, "$.a.b[1]"},
+		{`{"a": {"b": {"c": [1]}}}`, "$.a.b.c[0]"},
+		{`{"a": {"b": "1", "b": "2"}}`, "$.a.b"},
+		{`{"a": {"b": {"c" 1}}}`, "$.a.b.c"},
+	}
+	for _, test := range tests {
+		_, err := unmarshalJSONConfig(strings.NewReader(test.input))
+		if err == nil {
+			t.Errorf("%s: expected an error", test.input)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), test.location+": ") {
+			t.Errorf("%s: expected an error at %s, got: %s", test.input, test.location, err)
+		}
+	}
+}
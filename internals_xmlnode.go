@@ -25,10 +25,12 @@
 package seelog
 
 import (
+	"bytes"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 )
 
@@ -37,6 +39,15 @@ type xmlNode struct {
 	attributes map[string]string
 	children   []*xmlNode
 	value      string
+	nodePosition
+}
+
+// nodePosition is the place of a node in the config source, used in errors.
+type nodePosition struct {
+	location string // Path of the node. Empty - unknown
+	file     string // Config file of the node. Empty - unknown
+	line     int    // Position of the node in an XML config. 0 - unknown
+	column   int
 }
 
 func newNode() *xmlNode {
@@ -67,6 +78,44 @@ func (node *xmlNode) String() string {
 	return str
 }
 
+// marshalIndent returns the node as an XML document with escaped values, attributes in
+// alphabetical order and every element on its own line.
+func (node *xmlNode) marshalIndent(indent string) []byte {
+	var buf bytes.Buffer
+	node.writeIndented(&buf, "", indent)
+	return buf.Bytes()
+}
+
+func (node *xmlNode) writeIndented(buf *bytes.Buffer, prefix, indent string) {
+	buf.WriteString(prefix + "<" + node.name)
+
+	attrNames := make([]string, 0, len(node.attributes))
+	for attrName := range node.attributes {
+		attrNames = append(attrNames, attrName)
+	}
+	sort.Strings(attrNames)
+	for _, attrName := range attrNames {
+		buf.WriteString(" " + attrName + "=\"")
+		xml.EscapeText(buf, []byte(node.attributes[attrName]))
+		buf.WriteString("\"")
+	}
+
+	if len(node.children) == 0 && len(node.value) == 0 {
+		buf.WriteString("/>\n")
+		return
+	}
+	buf.WriteString(">")
+	xml.EscapeText(buf, []byte(node.value))
+	if len(node.children) != 0 {
+		buf.WriteString("\n")
+		for _, child := range node.children {
+			child.writeIndented(buf, prefix+indent, indent)
+		}
+		buf.WriteString(prefix)
+	}
+	buf.WriteString("</" + node.name + ">\n")
+}
+
 func (node *xmlNode) unmarshal(startEl xml.StartElement) error {
 	node.name = startEl.Name.Local
 
@@ -111,17 +160,48 @@ func unmarshalConfig(reader io.Reader) (*xmlNode, error) {
 		return nil, errors.New("xml contains more than one root element")
 	}
 
+	setXMLNodePaths(config, "")
 	return config, nil
 }
 
+// setXMLNodePaths sets the locations of the node and its children to their paths, like
+// '/seelog/outputs/file[2]'. Indexes are only added to elements with same-named siblings.
+func setXMLNodePaths(node *xmlNode, parentPath string) {
+	if len(node.location) == 0 {
+		node.location = parentPath + "/" + node.name
+	}
+	counts := make(map[string]int)
+	for _, child := range node.children {
+		counts[child.name]++
+	}
+	indexes := make(map[string]int)
+	for _, child := range node.children {
+		if counts[child.name] > 1 {
+			indexes[child.name]++
+			child.location = fmt.Sprintf("%s/%s[%d]", node.location, child.name, indexes[child.name])
+		}
+		setXMLNodePaths(child, node.location)
+	}
+}
+
+// setNodeFile sets the config file of the node and its children.
+func setNodeFile(node *xmlNode, file string) {
+	node.file = file
+	for _, child := range node.children {
+		setNodeFile(child, file)
+	}
+}
+
 func unmarshalNode(xmlParser *xml.Decoder, curToken xml.Token) (node *xmlNode, err error) {
 	firstLoop := true
 	for {
 		var tok xml.Token
+		line, column := 0, 0
 		if firstLoop && curToken != nil {
 			tok = curToken
 			firstLoop = false
 		} else {
+			line, column = xmlParser.InputPos()
 			tok, err = getNextToken(xmlParser)
 			if err != nil || tok == nil {
 				return
@@ -140,6 +220,7 @@ func unmarshalNode(xmlParser *xml.Decoder, curToken xml.Token) (node *xmlNode, e
 		case xml.StartElement:
 			if node == nil {
 				node = newNode()
+				node.line, node.column = line, column
 				err := node.unmarshal(tt)
 				if err != nil {
 					return nil, err
@@ -151,6 +232,7 @@ func unmarshalNode(xmlParser *xml.Decoder, curToken xml.Token) (node *xmlNode, e
 				}
 
 				if childNode != nil {
+					childNode.line, childNode.column = line, column
 					node.add(childNode)
 				} else {
 					return
@@ -188,9 +188,50 @@ func TestXmlNode(t *testing.T) {
 			continue
 		}
 
+		if err == nil {
+			// Positions are checked in TestXmlNodePositions.
+			clearNodePositions(parsedXML)
+		}
 		if err == nil && !reflect.DeepEqual(parsedXML, test.expected) {
 			t.Errorf("\n%s:\nXML input: %s\nExpected: %s. \nGot: %s\n", test.testName,
 				test.inputXML, test.expected, parsedXML)
 		}
 	}
 }
+
+func clearNodePositions(node *xmlNode) {
+	node.nodePosition = nodePosition{}
+	for _, child := range node.children {
+		clearNodePositions(child)
+	}
+}
+
+func TestXmlNodePositions(t *testing.T) {
+	config := `<seelog>
+	<outputs>
+		<console/>
+		<file path="a.log"/>
+		<file path="b.log"/>
+	</outputs>
+</seelog>`
+	node, err := unmarshalConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := node.children[0]
+	expected := []struct {
+		node     *xmlNode
+		position nodePosition
+	}{
+		{node, nodePosition{location: "/seelog", line: 1, column: 1}},
+		{outputs, nodePosition{location: "/seelog/outputs", line: 2, column: 2}},
+		{outputs.children[0], nodePosition{location: "/seelog/outputs/console", line: 3, column: 3}},
+		{outputs.children[1], nodePosition{location: "/seelog/outputs/file[1]", line: 4, column: 3}},
+		{outputs.children[2], nodePosition{location: "/seelog/outputs/file[2]", line: 5, column: 3}},
+	}
+	for _, test := range expected {
+		if test.node.nodePosition != test.position {
+			t.Errorf("expected position %+v, got %+v", test.position, test.node.nodePosition)
+		}
+	}
+}
@@ -53,10 +53,15 @@ func init() {
 
 	if Default == nil {
 		Default, err = LoggerFromConfigAsBytes([]byte("<seelog />"))
+		if err != nil {
+			// Wrong SEELOG_* environment overrides must not stop programs importing seelog.
+			reportInternalError(err)
+			Default, err = LoggerFromParamConfigAsBytes([]byte("<seelog />"), &CfgParseParams{IgnoreEnvOverrides: true})
+		}
 	}
 
 	if Disabled == nil {
-		Disabled, err = LoggerFromConfigAsBytes([]byte("<seelog levels=\"off\"/>"))
+		Disabled, err = LoggerFromParamConfigAsBytes([]byte("<seelog levels=\"off\"/>"), &CfgParseParams{IgnoreEnvOverrides: true})
 	}
 
 	if err != nil {
@@ -67,6 +72,17 @@ func init() {
 }
 
 func createLoggerFromFullConfig(config *configForParsing) (LoggerInterface, error) {
+	logger, err := createLoggerByType(config)
+	if err != nil {
+		return nil, err
+	}
+	if setter, ok := logger.(ClockSetter); ok {
+		config.Params.applyClock(setter)
+	}
+	return logger, nil
+}
+
+func createLoggerByType(config *configForParsing) (LoggerInterface, error) {
 	if config.LogType == syncloggerTypeFromString {
 		return NewSyncLogger(&config.logConfig), nil
 	} else if config.LogType == asyncLooploggerTypeFromString {
@@ -83,7 +99,7 @@ func createLoggerFromFullConfig(config *configForParsing) (LoggerInterface, erro
 		}
 
 		logger, err := NewAsyncTimerLogger(&config.logConfig, time.Duration(asyncInt.AsyncInterval))
-		if !ok {
+		if err != nil {
 			return nil, err
 		}
 
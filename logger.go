@@ -138,6 +138,7 @@ type commonLogger struct {
 	innerLogger   innerLoggerInterface
 	addStackDepth int // Additional stack depth needed for correct seelog caller context detection
 	customContext interface{}
+	clock         Clock // Source of message call time and async intervals. Must be accessed while holding m
 }
 
 func newCommonLogger(config *logConfig, internalLogger innerLoggerInterface) *commonLogger {
@@ -148,10 +149,26 @@ func newCommonLogger(config *logConfig, internalLogger innerLoggerInterface) *co
 	cLogger.unusedLevels = make([]bool, Off)
 	cLogger.fillUnusedLevels()
 	cLogger.innerLogger = internalLogger
+	cLogger.clock = SystemClock
 
 	return cLogger
 }
 
+// configOf returns the config of a logger created by this package.
+func configOf(logger LoggerInterface) (*logConfig, error) {
+	configured, ok := logger.(interface {
+		getConfig() *logConfig
+	})
+	if !ok {
+		return nil, fmt.Errorf("unexpected logger type %T", logger)
+	}
+	return configured.getConfig(), nil
+}
+
+func (cLogger *commonLogger) getConfig() *logConfig {
+	return cLogger.config
+}
+
 func (cLogger *commonLogger) SetAdditionalStackDepth(depth int) error {
 	if depth < 0 {
 		return fmt.Errorf("negative depth: %d", depth)
@@ -162,6 +179,23 @@ func (cLogger *commonLogger) SetAdditionalStackDepth(depth int) error {
 	return nil
 }
 
+// SetClock sets the clock used to get the call time of messages and, for
+// asynchronous loggers, to wait between queue processing. See ClockSetter.
+func (cLogger *commonLogger) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	cLogger.m.Lock()
+	cLogger.clock = clock
+	cLogger.m.Unlock()
+}
+
+func (cLogger *commonLogger) currentClock() Clock {
+	cLogger.m.Lock()
+	defer cLogger.m.Unlock()
+	return cLogger.clock
+}
+
 func (cLogger *commonLogger) Tracef(format string, params ...interface{}) {
 	cLogger.traceWithCallDepth(loggerFuncCallDepth, newLogFormattedMessage(format, params))
 }
@@ -290,7 +324,7 @@ func (cLogger *commonLogger) log(level LogLevel, message fmt.Stringer, stackCall
 	if cLogger.Closed() {
 		return
 	}
-	context, _ := specifyContext(stackCallDepth+cLogger.addStackDepth, cLogger.customContext)
+	context, _ := specifyContext(stackCallDepth+cLogger.addStackDepth, cLogger.customContext, cLogger.clock)
 	// Context errors are not reported because there are situations
 	// in which context errors are normal Seelog usage cases. For
 	// example in executables with stripped symbols.
@@ -0,0 +1,321 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+/*
+Package seelogtest provides helpers for testing code that logs using seelog.
+
+NewLogger creates a synchronous seelog logger which captures every message
+together with its level, formatted output and context, so that tests can check
+what was logged:
+
+  func TestLogin(t *testing.T) {
+      logger := seelogtest.NewLogger(t, nil)
+      logger.UseAsCurrent(t)
+
+      Login("user")
+
+      logger.AssertLogged(t, seelog.InfoLvl, "user logged in")
+      logger.AssertNotLogged(t, seelog.ErrorLvl, "")
+  }
+
+Options allow to set the format used for captured output, to use a deterministic
+Clock for the %Date/%Time/%Ns aliases and to mirror the formatted output to t.Log.
+*/
+package seelogtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cihub/seelog"
+)
+
+const receiverName = "seelogtest"
+
+// DefaultFormat is the format used for Record.Formatted when Options.Format is not set.
+const DefaultFormat = "%Date %Time [%LEV] %Msg%n"
+
+// Options represent optional settings of a capturing logger. Nil options are
+// equal to an empty Options value.
+type Options struct {
+	// Format is the seelog format used to produce Record.Formatted. DefaultFormat is used if empty.
+	Format string
+	// MinLevel is the min level of messages captured by the logger.
+	MinLevel seelog.LogLevel
+	// Clock, if set, is used as the logger clock, so that time format
+	// aliases produce stable output.
+	Clock *Clock
+	// MirrorToT enables mirroring of the formatted output to t.Log.
+	MirrorToT bool
+}
+
+// Record is a single message captured by Logger.
+type Record struct {
+	Level     seelog.LogLevel
+	Message   string // Message without formatting
+	Formatted string // Message formatted using Options.Format
+	Context   seelog.LogContextInterface
+}
+
+// CustomContext returns the custom context set by SetContext at the moment of the call.
+func (record Record) CustomContext() interface{} {
+	return record.Context.CustomContext()
+}
+
+func (record Record) String() string {
+	return fmt.Sprintf("[%s] %s", record.Level, record.Message)
+}
+
+// Logger is a seelog logger which captures all messages in memory.
+// It may be used everywhere a seelog.LoggerInterface is expected.
+type Logger struct {
+	seelog.LoggerInterface
+	receiver *receiver
+}
+
+// NewLogger creates a capturing logger. The logger is closed when the test
+// and all its subtests complete.
+func NewLogger(t testing.TB, options *Options) *Logger {
+	t.Helper()
+	if options == nil {
+		options = &Options{}
+	}
+	format := options.Format
+	if format == "" {
+		format = DefaultFormat
+	}
+	formatter, err := seelog.NewFormatter(format)
+	if err != nil {
+		t.Fatalf("seelogtest: invalid format: %s", err)
+	}
+
+	rec := &receiver{formatter: formatter}
+	if options.MirrorToT {
+		rec.t = t
+	}
+
+	config := fmt.Sprintf(`
+	<seelog type="sync" minlevel="%s">
+		<outputs formatid="msg">
+			<custom name="%s"/>
+		</outputs>
+		<formats>
+			<format id="msg" format="%%Msg"/>
+		</formats>
+	</seelog>`, options.MinLevel, receiverName)
+	// Captured records must not depend on the SEELOG_* variables of the developer's environment.
+	params := &seelog.CfgParseParams{
+		IgnoreEnvOverrides: true,
+		CustomReceiverProducers: map[string]seelog.CustomReceiverProducer{
+			receiverName: func(seelog.CustomReceiverInitArgs) (seelog.CustomReceiver, error) {
+				return rec, nil
+			},
+		},
+	}
+	if options.Clock != nil {
+		params.Clock = options.Clock
+	}
+	inner, err := seelog.LoggerFromParamConfigAsString(config, params)
+	if err != nil {
+		t.Fatalf("seelogtest: cannot create logger: %s", err)
+	}
+
+	logger := &Logger{inner, rec}
+	t.Cleanup(logger.Close)
+	return logger
+}
+
+// UseAsCurrent makes the logger the package level seelog logger (seelog.Current)
+// until the test completes. The previous logger is restored afterwards.
+func (logger *Logger) UseAsCurrent(t testing.TB) {
+	t.Helper()
+	previous := seelog.Current
+	if err := seelog.UseLogger(logger); err != nil {
+		t.Fatalf("seelogtest: %s", err)
+	}
+	t.Cleanup(func() {
+		seelog.UseLogger(previous)
+	})
+}
+
+// Records returns a copy of all captured records in the order they were logged.
+func (logger *Logger) Records() []Record {
+	return logger.receiver.snapshot()
+}
+
+// RecordsAt returns captured records with the specified level.
+func (logger *Logger) RecordsAt(level seelog.LogLevel) []Record {
+	var result []Record
+	for _, record := range logger.Records() {
+		if record.Level == level {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// Output returns the concatenated formatted output of all captured records.
+func (logger *Logger) Output() string {
+	var output []string
+	for _, record := range logger.Records() {
+		output = append(output, record.Formatted)
+	}
+	return strings.Join(output, "")
+}
+
+// Contains returns true if a record with the specified level and a message
+// containing substring was captured.
+func (logger *Logger) Contains(level seelog.LogLevel, substring string) bool {
+	return logger.count(level, substring) != 0
+}
+
+// Reset removes all captured records.
+func (logger *Logger) Reset() {
+	logger.receiver.reset()
+}
+
+// AssertLogged fails the test if no message with the specified level containing
+// substring was captured.
+func (logger *Logger) AssertLogged(t testing.TB, level seelog.LogLevel, substring string) {
+	t.Helper()
+	if !logger.Contains(level, substring) {
+		t.Errorf("expected %s message containing %q, got:\n%s", level, substring, logger.describe())
+	}
+}
+
+// AssertNotLogged fails the test if a message with the specified level containing
+// substring was captured. Use an empty substring to check that there are no
+// messages with the level at all.
+func (logger *Logger) AssertNotLogged(t testing.TB, level seelog.LogLevel, substring string) {
+	t.Helper()
+	if logger.Contains(level, substring) {
+		t.Errorf("unexpected %s message containing %q, got:\n%s", level, substring, logger.describe())
+	}
+}
+
+// AssertCount fails the test if the number of captured messages with the
+// specified level differs from count.
+func (logger *Logger) AssertCount(t testing.TB, level seelog.LogLevel, count int) {
+	t.Helper()
+	if got := logger.count(level, ""); got != count {
+		t.Errorf("expected %d %s messages, got %d:\n%s", count, level, got, logger.describe())
+	}
+}
+
+// AssertEmpty fails the test if any message was captured.
+func (logger *Logger) AssertEmpty(t testing.TB) {
+	t.Helper()
+	if records := logger.Records(); len(records) != 0 {
+		t.Errorf("expected no messages, got:\n%s", logger.describe())
+	}
+}
+
+func (logger *Logger) count(level seelog.LogLevel, substring string) int {
+	count := 0
+	for _, record := range logger.Records() {
+		if record.Level == level && strings.Contains(record.Message, substring) {
+			count++
+		}
+	}
+	return count
+}
+
+func (logger *Logger) describe() string {
+	records := logger.Records()
+	if len(records) == 0 {
+		return "    <no messages>"
+	}
+	lines := make([]string, len(records))
+	for i, record := range records {
+		lines[i] = "    " + record.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Clock is a deterministic clock. It only changes when Set or Advance is called.
+// Clock implements seelog.Clock, so it may also be used with seelog writers and loggers directly.
+type Clock struct {
+	*seelog.ManualClock
+}
+
+// NewClock creates a clock showing the specified time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{seelog.NewManualClock(now)}
+}
+
+type messageFormatter interface {
+	Format(message string, level seelog.LogLevel, context seelog.LogContextInterface) string
+}
+
+// receiver is the seelog custom receiver which stores messages for Logger.
+type receiver struct {
+	formatter messageFormatter
+	t         testing.TB
+	records   []Record
+	m         sync.Mutex
+}
+
+func (rec *receiver) ReceiveMessage(message string, level seelog.LogLevel, context seelog.LogContextInterface) error {
+	record := Record{
+		Level:     level,
+		Message:   message,
+		Formatted: rec.formatter.Format(message, level, context),
+		Context:   context,
+	}
+
+	rec.m.Lock()
+	rec.records = append(rec.records, record)
+	rec.m.Unlock()
+
+	if rec.t != nil {
+		rec.t.Log(strings.TrimRight(record.Formatted, "\r\n"))
+	}
+	return nil
+}
+
+func (rec *receiver) AfterParse(seelog.CustomReceiverInitArgs) error {
+	return nil
+}
+
+func (rec *receiver) Flush() {
+}
+
+func (rec *receiver) Close() error {
+	return nil
+}
+
+func (rec *receiver) snapshot() []Record {
+	rec.m.Lock()
+	defer rec.m.Unlock()
+	return append([]Record(nil), rec.records...)
+}
+
+func (rec *receiver) reset() {
+	rec.m.Lock()
+	defer rec.m.Unlock()
+	rec.records = nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package seelogtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cihub/seelog"
+)
+
+type tenant struct {
+	name string
+}
+
+func TestLoggerCaptures(t *testing.T) {
+	clock := NewClock(time.Date(2014, time.March, 1, 10, 20, 30, 0, time.UTC))
+	logger := NewLogger(t, &Options{Format: "%Date %Time %l %Msg", Clock: clock})
+
+	logger.SetContext(&tenant{"acme"})
+	logger.Infof("hello %s", "world")
+	clock.Advance(time.Second)
+	logger.Error("failed")
+
+	records := logger.Records()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Formatted != "2014-03-01 10:20:30 i hello world" {
+		t.Errorf("unexpected formatted output: %q", records[0].Formatted)
+	}
+	if records[1].Formatted != "2014-03-01 10:20:31 e failed" {
+		t.Errorf("unexpected formatted output: %q", records[1].Formatted)
+	}
+	if ctx, ok := records[0].CustomContext().(*tenant); !ok || ctx.name != "acme" {
+		t.Errorf("unexpected custom context: %v", records[0].CustomContext())
+	}
+
+	logger.AssertLogged(t, seelog.InfoLvl, "hello")
+	logger.AssertNotLogged(t, seelog.InfoLvl, "failed")
+	logger.AssertCount(t, seelog.ErrorLvl, 1)
+	if logger.Output() != "2014-03-01 10:20:30 i hello world2014-03-01 10:20:31 e failed" {
+		t.Errorf("unexpected output: %q", logger.Output())
+	}
+
+	logger.Reset()
+	logger.AssertEmpty(t)
+}
+
+func TestLoggerMinLevel(t *testing.T) {
+	logger := NewLogger(t, &Options{MinLevel: seelog.WarnLvl, MirrorToT: true})
+
+	logger.Debug("hidden")
+	logger.Warn("shown")
+
+	logger.AssertNotLogged(t, seelog.DebugLvl, "")
+	logger.AssertLogged(t, seelog.WarnLvl, "shown")
+}
+
+func TestLoggerIgnoresEnvOverrides(t *testing.T) {
+	t.Setenv(seelog.MinLevelEnvVariable, "critical")
+	t.Setenv(seelog.FormatEnvVariable, "%Level")
+	logger := NewLogger(t, &Options{Format: "%Msg"})
+
+	logger.Info("message")
+
+	logger.AssertLogged(t, seelog.InfoLvl, "message")
+	if logger.Output() != "message" {
+		t.Errorf("unexpected output: %q", logger.Output())
+	}
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	logger := NewLogger(t, nil)
+	logger.Info("message")
+
+	mock := &testing.T{}
+	logger.AssertLogged(mock, seelog.ErrorLvl, "message")
+	if !mock.Failed() {
+		t.Error("AssertLogged should fail for a missing message")
+	}
+
+	mock = &testing.T{}
+	logger.AssertEmpty(mock)
+	if !mock.Failed() {
+		t.Error("AssertEmpty should fail when messages were captured")
+	}
+}
+
+func TestUseAsCurrent(t *testing.T) {
+	previous := seelog.Current
+
+	t.Run("sub", func(t *testing.T) {
+		logger := NewLogger(t, nil)
+		logger.UseAsCurrent(t)
+
+		seelog.Info("package level")
+		logger.AssertLogged(t, seelog.InfoLvl, "package level")
+	})
+
+	if seelog.Current != previous {
+		t.Error("previous logger was not restored")
+	}
+}
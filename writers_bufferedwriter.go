@@ -40,6 +40,8 @@ type bufferedWriter struct {
 	innerWriter io.Writer     // inner writer
 	buffer      *bufio.Writer // buffered wrapper for inner writer
 	bufferSize  int           // max size of data chunk in bytes
+	clock       Clock         // clock used to wait for flushPeriod
+	clockSet    chan struct{} // notifies the flushing goroutine about a clock change
 }
 
 // NewBufferedWriter creates a new buffered writer struct.
@@ -71,6 +73,8 @@ func NewBufferedWriter(innerWriter io.Writer, bufferSize int, flushPeriod time.D
 	newWriter.bufferSize = bufferSize
 	newWriter.flushPeriod = flushPeriod * 1e6
 	newWriter.bufferMutex = new(sync.Mutex)
+	newWriter.clock = SystemClock
+	newWriter.clockSet = make(chan struct{}, 1)
 
 	if flushPeriod != 0 {
 		go newWriter.flushPeriodically()
@@ -124,12 +128,35 @@ func (bufWriter *bufferedWriter) Close() error {
 	return nil
 }
 
+// Flush writes the buffered data to the inner writer and flushes it too.
 func (bufWriter *bufferedWriter) Flush() {
 
 	bufWriter.bufferMutex.Lock()
 	defer bufWriter.bufferMutex.Unlock()
 
 	bufWriter.flushInner()
+	if flusher, ok := bufWriter.innerWriter.(flusherInterface); ok {
+		flusher.Flush()
+	}
+}
+
+func (bufWriter *bufferedWriter) syncsOnLevel(level LogLevel) bool {
+	syncer, ok := bufWriter.innerWriter.(levelSyncer)
+	return ok && syncer.syncsOnLevel(level)
+}
+
+// syncData writes the buffered data to the inner writer and makes it sync.
+func (bufWriter *bufferedWriter) syncData() error {
+	bufWriter.bufferMutex.Lock()
+	defer bufWriter.bufferMutex.Unlock()
+
+	if _, err := bufWriter.flushInner(); err != nil {
+		return err
+	}
+	if syncer, ok := bufWriter.innerWriter.(levelSyncer); ok {
+		return syncer.syncData()
+	}
+	return nil
 }
 
 func (bufWriter *bufferedWriter) flushInner() (n int, err error) {
@@ -146,12 +173,48 @@ func (bufWriter *bufferedWriter) flushBuffer() {
 	bufWriter.buffer.Flush()
 }
 
+// Reopen flushes the buffer and reopens the inner writer, if it supports reopening.
+func (bufWriter *bufferedWriter) Reopen() error {
+	bufWriter.bufferMutex.Lock()
+	defer bufWriter.bufferMutex.Unlock()
+
+	bufWriter.flushInner()
+	if r, ok := bufWriter.innerWriter.(reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// SetClock sets the clock used to wait for flush periods.
+func (bufWriter *bufferedWriter) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	bufWriter.bufferMutex.Lock()
+	bufWriter.clock = clock
+	bufWriter.bufferMutex.Unlock()
+
+	select {
+	case bufWriter.clockSet <- struct{}{}:
+	default:
+	}
+}
+
+func (bufWriter *bufferedWriter) currentClock() Clock {
+	bufWriter.bufferMutex.Lock()
+	defer bufWriter.bufferMutex.Unlock()
+	return bufWriter.clock
+}
+
 func (bufWriter *bufferedWriter) flushPeriodically() {
 	if bufWriter.flushPeriod > 0 {
-		ticker := time.NewTicker(bufWriter.flushPeriod)
 		for {
-			<-ticker.C
-			bufWriter.flushBuffer()
+			select {
+			case <-bufWriter.currentClock().After(bufWriter.flushPeriod):
+				bufWriter.flushBuffer()
+			case <-bufWriter.clockSet:
+				// Start waiting using the new clock
+			}
 		}
 	}
 }
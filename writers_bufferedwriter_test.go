@@ -25,7 +25,10 @@
 package seelog
 
 import (
+	"bytes"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestChunkWriteOnFilling(t *testing.T) {
@@ -60,3 +63,49 @@ func TestBigMessageMustPassMemoryBuffer(t *testing.T) {
 	writer.ExpectBytes(bytes)
 	bufferedWriter.Write(bytes)
 }
+
+type lockedBuffer struct {
+	buf bytes.Buffer
+	m   sync.Mutex
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.String()
+}
+
+func TestPeriodicFlushUsesClock(t *testing.T) {
+	inner := new(lockedBuffer)
+	bufferedWriter, err := NewBufferedWriter(inner, 1024, 1000)
+	if err != nil {
+		t.Fatalf("Unexpected buffered writer creation error: %s", err.Error())
+	}
+	clock := NewManualClock(time.Date(2015, time.March, 1, 10, 0, 0, 0, time.UTC))
+	bufferedWriter.SetClock(clock)
+
+	bufferedWriter.Write([]byte("abc"))
+	waitFor(t, func() bool { return clock.Waiters() > 0 })
+	if inner.String() != "" {
+		t.Fatalf("data flushed before the flush period: %q", inner.String())
+	}
+
+	clock.Advance(time.Second)
+	waitFor(t, func() bool { return inner.String() == "abc" })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -25,27 +25,285 @@
 package seelog
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 )
 
+// reopener is implemented by writers which keep files open and may reopen them.
+type reopener interface {
+	Reopen() error
+}
+
+// ReopenFiles makes all the file outputs of the logger reopen their files by name.
+// Call it after the log files were rotated by an external tool, e.g. in a SIGHUP handler.
+// All outputs are reopened even if some of them fail, the first error is returned.
+func ReopenFiles(logger LoggerInterface) error {
+	config, err := configOf(logger)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	walkOutputs(config.RootDispatcher, func(output interface{}) bool {
+		if writer, ok := output.(*formattedWriter); ok {
+			output = writer.Writer()
+		}
+		if r, ok := output.(reopener); ok {
+			if err := r.Reopen(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return true
+	})
+	return firstErr
+}
+
+// SyncMode defines when file writers sync written data to the storage (fsync).
+type SyncMode uint8
+
+const (
+	// SyncNever leaves syncing to the operating system.
+	SyncNever SyncMode = iota
+	// SyncWrite syncs after every write.
+	SyncWrite
+	// SyncLevel syncs after messages of SyncPolicy.Level and above.
+	SyncLevel
+	// SyncInterval syncs on the first write after SyncPolicy.Interval since the last sync.
+	SyncInterval
+)
+
+var syncModeToStringMap = map[SyncMode]string{
+	SyncNever:    "never",
+	SyncWrite:    "write",
+	SyncLevel:    "level",
+	SyncInterval: "interval",
+}
+
+func (mode SyncMode) String() string {
+	return syncModeToStringMap[mode]
+}
+
+func syncModeFromString(str string) (SyncMode, bool) {
+	for mode, modeStr := range syncModeToStringMap {
+		if modeStr == str {
+			return mode, true
+		}
+	}
+	return 0, false
+}
+
+// SyncPolicy defines when file writers sync written data to the storage. With any mode
+// except SyncNever, files are also synced on Flush, before rolls and on Close.
+type SyncPolicy struct {
+	Mode     SyncMode
+	Level    LogLevel      // Minimal level of messages which cause syncing in SyncLevel mode
+	Interval time.Duration // Minimal time between syncs in SyncInterval mode
+}
+
+func (policy SyncPolicy) validate() error {
+	switch policy.Mode {
+	case SyncNever, SyncWrite:
+	case SyncLevel:
+		if _, ok := levelToStringRepresentations[policy.Level]; !ok || policy.Level == Off {
+			return fmt.Errorf("incorrect sync level: %d", policy.Level)
+		}
+	case SyncInterval:
+		if policy.Interval <= 0 {
+			return errors.New("sync interval must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("unknown sync mode: %d", policy.Mode)
+	}
+	return nil
+}
+
+func (policy SyncPolicy) String() string {
+	switch policy.Mode {
+	case SyncLevel:
+		return fmt.Sprintf("%s %s", policy.Mode, policy.Level)
+	case SyncInterval:
+		return fmt.Sprintf("%s %v", policy.Mode, policy.Interval)
+	}
+	return policy.Mode.String()
+}
+
+// syncState tracks the syncs of a file according to a policy.
+type syncState struct {
+	policy   SyncPolicy
+	lastSync time.Time
+}
+
+// afterWrite syncs the file if the policy requires it after every write.
+func (state *syncState) afterWrite(f *os.File, now time.Time) error {
+	switch state.policy.Mode {
+	case SyncWrite:
+	case SyncInterval:
+		if now.Sub(state.lastSync) < state.policy.Interval {
+			return nil
+		}
+	default:
+		return nil
+	}
+	state.lastSync = now
+	return f.Sync()
+}
+
+// beforeClose syncs the file if the policy requires syncing at all.
+func (state *syncState) beforeClose(f *os.File) error {
+	if state.policy.Mode == SyncNever || f == nil {
+		return nil
+	}
+	return f.Sync()
+}
+
+func (state *syncState) syncsOnLevel(level LogLevel) bool {
+	return state.policy.Mode == SyncLevel && level >= state.policy.Level
+}
+
+// levelSyncer is implemented by writers which sync data to the storage after messages
+// of certain levels. Writers get only formatted messages, so the callers holding the
+// level (formattedWriter) ask them to sync.
+type levelSyncer interface {
+	syncsOnLevel(level LogLevel) bool
+	syncData() error
+}
+
+// syncLevel syncs the writer, if it needs syncing after a message of the level.
+func syncLevel(writer io.Writer, level LogLevel) error {
+	if syncer, ok := writer.(levelSyncer); ok && syncer.syncsOnLevel(level) {
+		return syncer.syncData()
+	}
+	return nil
+}
+
 // fileWriter is used to write to a file.
 type fileWriter struct {
-	innerWriter io.WriteCloser
-	fileName    string
+	innerWriter    io.WriteCloser
+	fileName       string
+	reopenInterval time.Duration // Period of checks whether the file was moved or removed. 0 - no checks
+	lastCheck      time.Time
+	clock          Clock
+	lock           *processLock // Lock shared with other processes. nil - no locking
+	syncing        syncState
+	m              sync.Mutex
 }
 
 // Creates a new file and a corresponding writer. Returns error, if the file couldn't be created.
 func NewFileWriter(fileName string) (writer *fileWriter, err error) {
 	newWriter := new(fileWriter)
 	newWriter.fileName = fileName
+	newWriter.clock = SystemClock
 
 	return newWriter, nil
 }
 
+// SetReopenInterval makes the writer check every interval whether the file was moved
+// or removed, e.g. by logrotate, and reopen the file by its name if so. 0 turns the checks off.
+func (fw *fileWriter) SetReopenInterval(interval time.Duration) {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	fw.reopenInterval = interval
+}
+
+// SetLocking makes every write hold an advisory lock (flock) shared by all processes
+// writing to the file, and reopen the file if it was replaced by another process.
+// Locking is only supported on Linux.
+func (fw *fileWriter) SetLocking(enabled bool) error {
+	var lock *processLock
+	if enabled {
+		var err error
+		if lock, err = newProcessLock(fw.fileName); err != nil {
+			return err
+		}
+	}
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if fw.lock != nil {
+		fw.lock.Close()
+	}
+	fw.lock = lock
+	return nil
+}
+
+// SetSyncPolicy sets when the written data is synced to the storage.
+func (fw *fileWriter) SetSyncPolicy(policy SyncPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	fw.syncing.policy = policy
+	return nil
+}
+
+// Flush syncs the file, unless the sync policy is SyncNever.
+func (fw *fileWriter) Flush() {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if err := fw.syncing.beforeClose(fw.file()); err != nil {
+		reportInternalError(err)
+	}
+}
+
+func (fw *fileWriter) syncsOnLevel(level LogLevel) bool {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	return fw.syncing.syncsOnLevel(level)
+}
+
+func (fw *fileWriter) syncData() error {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if f := fw.file(); f != nil {
+		fw.syncing.lastSync = fw.clock.Now()
+		return f.Sync()
+	}
+	return nil
+}
+
+// file returns the open file, nil if the file is not open.
+func (fw *fileWriter) file() *os.File {
+	f, _ := fw.innerWriter.(*os.File)
+	return f
+}
+
+// SetClock sets the clock used to schedule the reopen checks.
+func (fw *fileWriter) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	fw.clock = clock
+}
+
+// Reopen closes the file, so that it is opened by its name again on the next write.
+func (fw *fileWriter) Reopen() error {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	return fw.close()
+}
+
 func (fw *fileWriter) Close() error {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if fw.lock != nil {
+		if err := fw.lock.Close(); err != nil {
+			return err
+		}
+	}
+	return fw.close()
+}
+
+func (fw *fileWriter) close() error {
+	if err := fw.syncing.beforeClose(fw.file()); err != nil {
+		reportInternalError(err)
+	}
 	if fw.innerWriter != nil {
 		err := fw.innerWriter.Close()
 		if err != nil {
@@ -58,12 +316,53 @@ func (fw *fileWriter) Close() error {
 
 // Create folder and file on WriteLog/Write first call
 func (fw *fileWriter) Write(bytes []byte) (n int, err error) {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+
+	if fw.lock != nil {
+		if err := fw.lock.Lock(); err != nil {
+			return 0, err
+		}
+		defer fw.lock.Unlock()
+	}
+
+	if fw.innerWriter != nil && fw.needsToReopen() {
+		if err := fw.close(); err != nil {
+			reportInternalError(err)
+			fw.innerWriter = nil
+		}
+	}
 	if fw.innerWriter == nil {
 		if err := fw.createFile(); err != nil {
 			return 0, err
 		}
 	}
-	return fw.innerWriter.Write(bytes)
+	n, err = fw.innerWriter.Write(bytes)
+	if err != nil {
+		return n, err
+	}
+	if f := fw.file(); f != nil {
+		err = fw.syncing.afterWrite(f, fw.clock.Now())
+	}
+	return n, err
+}
+
+func (fw *fileWriter) needsToReopen() bool {
+	if fw.lock != nil {
+		// Other processes may replace the file at any moment, so check every write.
+		f, ok := fw.innerWriter.(*os.File)
+		return ok && fileReplaced(f, fw.fileName)
+	}
+	if fw.reopenInterval <= 0 {
+		return false
+	}
+	now := fw.clock.Now()
+	if now.Sub(fw.lastCheck) < fw.reopenInterval {
+		return false
+	}
+	fw.lastCheck = now
+	f, ok := fw.innerWriter.(*os.File)
+	return ok && fileReplaced(f, fw.fileName)
 }
 
 func (fw *fileWriter) createFile() error {
@@ -78,15 +377,27 @@ func (fw *fileWriter) createFile() error {
 	}
 
 	// If exists
-	fw.innerWriter, err = os.OpenFile(fw.fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, defaultFilePermissions)
+	file, err := os.OpenFile(fw.fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, defaultFilePermissions)
 
 	if err != nil {
 		return err
 	}
 
+	fw.innerWriter = file
+	fw.lastCheck = fw.clock.Now()
 	return nil
 }
 
 func (fw *fileWriter) String() string {
-	return fmt.Sprintf("File writer: %s", fw.fileName)
+	str := fmt.Sprintf("File writer: %s", fw.fileName)
+	if fw.reopenInterval > 0 {
+		str += fmt.Sprintf(", reopen interval: %v", fw.reopenInterval)
+	}
+	if fw.lock != nil {
+		str += ", locked"
+	}
+	if fw.syncing.policy.Mode != SyncNever {
+		str += fmt.Sprintf(", sync: %s", fw.syncing.policy)
+	}
+	return str
 }
@@ -27,10 +27,12 @@ package seelog
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -255,3 +257,186 @@ func (tester *fileWriterTester) checkJustRequiredFilesExist(testCase *fileWriter
 		}
 	}
 }
+
+func TestFileWriterReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_reopen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	writer, err := NewFileWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	clock := NewManualClock(time.Date(2015, time.March, 1, 10, 0, 0, 0, time.UTC))
+	writer.SetClock(clock)
+	writer.SetReopenInterval(time.Minute)
+
+	mustWrite := func(str string) {
+		if _, err := writer.Write([]byte(str)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustRead := func(path, expected string) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, string(data))
+		}
+	}
+
+	mustWrite("first\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is not checked until the interval passes.
+	mustWrite("second\n")
+	clock.Advance(time.Minute)
+	mustWrite("third\n")
+	mustRead(path+".1", "first\nsecond\n")
+	mustRead(path, "third\n")
+
+	// Forced reopen.
+	if err := os.Rename(path, path+".2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	mustWrite("fourth\n")
+	mustRead(path+".2", "third\n")
+	mustRead(path, "fourth\n")
+}
+
+func TestReopenFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_reopenfiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	rollingPath := filepath.Join(dir, "rolling.log")
+	logger, err := LoggerFromConfigAsString(`
+<seelog type="sync">
+	<outputs formatid="msg">
+		<buffered size="1000">
+			<file path="` + path + `"/>
+		</buffered>
+		<rollingfile type="size" filename="` + rollingPath + `" maxsize="1000"/>
+	</outputs>
+	<formats>
+		<format id="msg" format="%Msg%n"/>
+	</formats>
+</seelog>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logger.Info("first")
+	logger.Flush()
+	for _, p := range []string{path, rollingPath} {
+		if err := os.Rename(p, p+".old"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ReopenFiles(logger); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("second")
+	logger.Flush()
+
+	for _, p := range []string{path, rollingPath} {
+		for file, expected := range map[string]string{p + ".old": "first\n", p: "second\n"} {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != expected {
+				t.Errorf("%s: expected %q, got %q", file, expected, string(data))
+			}
+		}
+	}
+}
+
+func TestFileWriterSyncPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	writer, err := NewFileWriter(filepath.Join(dir, "interval.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.SetClock(clock)
+	if err := writer.SetSyncPolicy(SyncPolicy{Mode: SyncInterval, Interval: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []struct {
+		advance  time.Duration
+		lastSync time.Time
+	}{
+		{0, start},
+		{30 * time.Second, start},
+		{30 * time.Second, start.Add(time.Minute)},
+	} {
+		clock.Advance(step.advance)
+		if _, err := writer.Write([]byte("msg\n")); err != nil {
+			t.Fatal(err)
+		}
+		if !writer.syncing.lastSync.Equal(step.lastSync) {
+			t.Errorf("at %v expected last sync at %v, got %v", clock.Now(), step.lastSync, writer.syncing.lastSync)
+		}
+	}
+
+	// Messages of the sync level are written through the buffer.
+	path := filepath.Join(dir, "level.log")
+	fileWriter, err := NewFileWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fileWriter.Close()
+	if err := fileWriter.SetSyncPolicy(SyncPolicy{Mode: SyncLevel, Level: ErrorLvl}); err != nil {
+		t.Fatal(err)
+	}
+	bufWriter, err := NewBufferedWriter(fileWriter, 1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer2, _ := NewFormattedWriter(bufWriter, onlyMessageFormatForTest)
+	context, err := currentContext(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []struct {
+		level    LogLevel
+		expected string
+	}{
+		{InfoLvl, ""},
+		{ErrorLvl, "infoerror"},
+	} {
+		if err := writer2.Write(msg.level.String(), msg.level, context); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadFile(path)
+		if string(data) != msg.expected {
+			t.Errorf("after %s expected %q in file, got %q", msg.level, msg.expected, string(data))
+		}
+	}
+
+	if err := writer.SetSyncPolicy(SyncPolicy{Mode: SyncLevel, Level: Off}); err == nil {
+		t.Error("expected an error for the Off sync level")
+	}
+}
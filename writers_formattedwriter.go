@@ -45,8 +45,10 @@ func NewFormattedWriter(writer io.Writer, formatter *formatter) (*formattedWrite
 
 func (formattedWriter *formattedWriter) Write(message string, level LogLevel, context LogContextInterface) error {
 	str := formattedWriter.formatter.Format(message, level, context)
-	_, err := formattedWriter.writer.Write([]byte(str))
-	return err
+	if _, err := formattedWriter.writer.Write([]byte(str)); err != nil {
+		return err
+	}
+	return syncLevel(formattedWriter.writer, level)
 }
 
 func (formattedWriter *formattedWriter) String() string {
@@ -0,0 +1,318 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package seelog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRecord is a single message kept by a '<memory>' output.
+type MemoryRecord struct {
+	Level         LogLevel
+	Time          time.Time
+	Message       string // Message without formatting
+	Formatted     string // Message formatted using the output format
+	Func          string
+	File          string // Caller's file short path
+	Line          int
+	CustomContext interface{}
+}
+
+// MemoryFilter describes which records are returned by MemoryOutput.Snapshot
+// or delivered by MemoryOutput.Subscribe. Zero fields do not filter anything,
+// so a nil or empty filter matches all records.
+type MemoryFilter struct {
+	// Levels, if not empty, is the list of allowed levels.
+	Levels []LogLevel
+	// MinLevel is the minimal allowed level.
+	MinLevel LogLevel
+	// Since excludes records logged before the specified time.
+	Since time.Time
+	// Contains excludes records whose message doesn't contain the substring.
+	Contains string
+	// FuncPattern excludes records whose caller func doesn't match the pattern.
+	// Pattern syntax is the same as in exceptions: '*' matches any sequence of symbols.
+	FuncPattern string
+	// Limit, if positive, is the max number of the most recent records returned by Snapshot.
+	Limit int
+}
+
+func (filter *MemoryFilter) matches(record *MemoryRecord) bool {
+	if filter == nil {
+		return true
+	}
+	if record.Level < filter.MinLevel {
+		return false
+	}
+	if len(filter.Levels) != 0 {
+		found := false
+		for _, level := range filter.Levels {
+			if level == record.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !filter.Since.IsZero() && record.Time.Before(filter.Since) {
+		return false
+	}
+	if filter.Contains != "" && !strings.Contains(record.Message, filter.Contains) {
+		return false
+	}
+	if filter.FuncPattern != "" && !stringMatchesPattern(splitPattern(filter.FuncPattern), record.Func) {
+		return false
+	}
+	return true
+}
+
+// MemoryOutput gives access to the records kept by a '<memory>' output of a running logger.
+// Use MemoryOutputByName to get it.
+type MemoryOutput interface {
+	// Name returns the value of the 'name' attribute of the output.
+	Name() string
+	// Capacity returns the max number of records kept by the output.
+	Capacity() int
+	// Len returns the current number of records kept by the output.
+	Len() int
+	// Snapshot returns a copy of the kept records matching the filter, oldest first.
+	Snapshot(filter *MemoryFilter) []MemoryRecord
+	// Subscribe returns a channel which receives all new records matching the filter
+	// and a func that cancels the subscription. Records are dropped if the channel
+	// buffer is full. The channel is closed on cancel or when the output is closed.
+	Subscribe(filter *MemoryFilter, bufferSize int) (<-chan MemoryRecord, func())
+	// Dump writes formatted messages of the records matching the filter to w.
+	Dump(w io.Writer, filter *MemoryFilter) error
+	// Clear removes all kept records.
+	Clear()
+}
+
+// MemoryOutputByName looks for a '<memory>' output with the specified name
+// in the dispatcher tree of the logger.
+func MemoryOutputByName(logger LoggerInterface, name string) (MemoryOutput, error) {
+	config, err := configOf(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *memoryWriter
+	walkOutputs(config.RootDispatcher, func(output interface{}) bool {
+		if mw, ok := output.(*memoryWriter); ok && mw.name == name {
+			found = mw
+			return false
+		}
+		return true
+	})
+	if found == nil {
+		return nil, fmt.Errorf("memory output '%s' not found", name)
+	}
+	return found, nil
+}
+
+type memorySubscription struct {
+	filter *MemoryFilter
+	ch     chan MemoryRecord
+}
+
+// memoryWriter keeps the last 'capacity' messages in a ring buffer.
+type memoryWriter struct {
+	formatter     *formatter
+	name          string
+	records       []MemoryRecord
+	start         int // Index of the oldest record
+	count         int
+	subscriptions map[*memorySubscription]bool
+	closed        bool
+	m             sync.Mutex
+}
+
+// NewMemoryWriter creates a new memory writer which keeps at most 'capacity' last messages.
+func NewMemoryWriter(formatter *formatter, name string, capacity int) (*memoryWriter, error) {
+	if formatter == nil {
+		return nil, errors.New("formatter cannot be nil")
+	}
+	if len(name) == 0 {
+		return nil, errors.New("memory writer name cannot be empty")
+	}
+	if capacity <= 0 {
+		return nil, fmt.Errorf("capacity can not be less or equal to 0. Got: %d", capacity)
+	}
+
+	return &memoryWriter{
+		formatter:     formatter,
+		name:          name,
+		records:       make([]MemoryRecord, capacity),
+		subscriptions: make(map[*memorySubscription]bool),
+	}, nil
+}
+
+func (mw *memoryWriter) Dispatch(
+	message string,
+	level LogLevel,
+	context LogContextInterface,
+	errorFunc func(err error)) {
+
+	record := MemoryRecord{
+		Level:         level,
+		Time:          context.CallTime(),
+		Message:       message,
+		Formatted:     mw.formatter.Format(message, level, context),
+		Func:          context.Func(),
+		File:          context.ShortPath(),
+		Line:          context.Line(),
+		CustomContext: context.CustomContext(),
+	}
+
+	mw.m.Lock()
+	defer mw.m.Unlock()
+
+	if mw.closed {
+		return
+	}
+
+	capacity := len(mw.records)
+	if mw.count < capacity {
+		mw.records[(mw.start+mw.count)%capacity] = record
+		mw.count++
+	} else {
+		mw.records[mw.start] = record
+		mw.start = (mw.start + 1) % capacity
+	}
+
+	for sub := range mw.subscriptions {
+		if !sub.filter.matches(&record) {
+			continue
+		}
+		select {
+		case sub.ch <- record:
+		default:
+		}
+	}
+}
+
+func (mw *memoryWriter) Flush() {
+}
+
+// Close cancels all subscriptions. Kept records remain accessible.
+func (mw *memoryWriter) Close() error {
+	mw.m.Lock()
+	defer mw.m.Unlock()
+
+	mw.closed = true
+	for sub := range mw.subscriptions {
+		close(sub.ch)
+		delete(mw.subscriptions, sub)
+	}
+	return nil
+}
+
+func (mw *memoryWriter) Name() string {
+	return mw.name
+}
+
+func (mw *memoryWriter) Capacity() int {
+	return len(mw.records)
+}
+
+func (mw *memoryWriter) Len() int {
+	mw.m.Lock()
+	defer mw.m.Unlock()
+	return mw.count
+}
+
+func (mw *memoryWriter) Snapshot(filter *MemoryFilter) []MemoryRecord {
+	mw.m.Lock()
+	defer mw.m.Unlock()
+
+	var result []MemoryRecord
+	for i := 0; i < mw.count; i++ {
+		record := &mw.records[(mw.start+i)%len(mw.records)]
+		if filter.matches(record) {
+			result = append(result, *record)
+		}
+	}
+
+	if filter != nil && filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result
+}
+
+func (mw *memoryWriter) Subscribe(filter *MemoryFilter, bufferSize int) (<-chan MemoryRecord, func()) {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	sub := &memorySubscription{filter, make(chan MemoryRecord, bufferSize)}
+
+	mw.m.Lock()
+	defer mw.m.Unlock()
+
+	if mw.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	mw.subscriptions[sub] = true
+
+	cancel := func() {
+		mw.m.Lock()
+		defer mw.m.Unlock()
+		if mw.subscriptions[sub] {
+			delete(mw.subscriptions, sub)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, cancel
+}
+
+func (mw *memoryWriter) Dump(w io.Writer, filter *MemoryFilter) error {
+	for _, record := range mw.Snapshot(filter) {
+		if _, err := io.WriteString(w, record.Formatted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mw *memoryWriter) Clear() {
+	mw.m.Lock()
+	defer mw.m.Unlock()
+
+	for i := range mw.records {
+		mw.records[i] = MemoryRecord{}
+	}
+	mw.start = 0
+	mw.count = 0
+}
+
+func (mw *memoryWriter) String() string {
+	return fmt.Sprintf("Memory writer: name: %s, capacity: %d, format: %s", mw.name, len(mw.records), mw.formatter)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2012 - Cloud Instruments Co., Ltd.
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package seelog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestMemoryWriterRing(t *testing.T) {
+	writer, err := NewMemoryWriter(onlyMessageFormatForTest, "test", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	context, err := currentContext(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		writer.Dispatch(msg, InfoLvl, context, func(err error) { t.Error(err) })
+	}
+
+	records := writer.Snapshot(nil)
+	if len(records) != 3 || writer.Len() != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	for i, expected := range []string{"c", "d", "e"} {
+		if records[i].Message != expected || records[i].Formatted != expected {
+			t.Errorf("record %d: expected %s, got %s", i, expected, records[i].Message)
+		}
+		if records[i].Level != InfoLvl {
+			t.Errorf("record %d: wrong level %s", i, records[i].Level)
+		}
+	}
+
+	writer.Clear()
+	if writer.Len() != 0 {
+		t.Errorf("expected empty writer after Clear, got %d records", writer.Len())
+	}
+}
+
+func TestMemoryWriterFilter(t *testing.T) {
+	writer, _ := NewMemoryWriter(onlyMessageFormatForTest, "test", 10)
+	context, _ := currentContext(nil)
+
+	writer.Dispatch("audit: login", InfoLvl, context, nil)
+	writer.Dispatch("audit: logout", ErrorLvl, context, nil)
+	writer.Dispatch("other", ErrorLvl, context, nil)
+
+	if n := len(writer.Snapshot(&MemoryFilter{MinLevel: ErrorLvl})); n != 2 {
+		t.Errorf("MinLevel filter: expected 2 records, got %d", n)
+	}
+	if n := len(writer.Snapshot(&MemoryFilter{Levels: []LogLevel{InfoLvl}})); n != 1 {
+		t.Errorf("Levels filter: expected 1 record, got %d", n)
+	}
+	if n := len(writer.Snapshot(&MemoryFilter{Contains: "audit:"})); n != 2 {
+		t.Errorf("Contains filter: expected 2 records, got %d", n)
+	}
+	if n := len(writer.Snapshot(&MemoryFilter{FuncPattern: "github.com/cihub/seelog.*"})); n != 3 {
+		t.Errorf("FuncPattern filter: expected 3 records, got %d", n)
+	}
+	if n := len(writer.Snapshot(&MemoryFilter{Since: time.Now().Add(time.Hour)})); n != 0 {
+		t.Errorf("Since filter: expected 0 records, got %d", n)
+	}
+	limited := writer.Snapshot(&MemoryFilter{Limit: 1})
+	if len(limited) != 1 || limited[0].Message != "other" {
+		t.Errorf("Limit filter: expected the last record, got %v", limited)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Dump(&buf, &MemoryFilter{Contains: "audit:"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "audit: loginaudit: logout" {
+		t.Errorf("unexpected dump: %s", buf.String())
+	}
+}
+
+func TestMemoryWriterSubscribe(t *testing.T) {
+	writer, _ := NewMemoryWriter(onlyMessageFormatForTest, "test", 10)
+	context, _ := currentContext(nil)
+
+	ch, cancel := writer.Subscribe(&MemoryFilter{MinLevel: WarnLvl}, 10)
+	writer.Dispatch("skipped", InfoLvl, context, nil)
+	writer.Dispatch("delivered", WarnLvl, context, nil)
+	cancel()
+	writer.Dispatch("after cancel", WarnLvl, context, nil)
+
+	var got []string
+	for record := range ch {
+		got = append(got, record.Message)
+	}
+	if len(got) != 1 || got[0] != "delivered" {
+		t.Errorf("unexpected subscription records: %v", got)
+	}
+
+	ch, _ = writer.Subscribe(nil, 1)
+	writer.Close()
+	if _, ok := <-ch; ok {
+		t.Error("expected subscription channel to be closed after Close")
+	}
+}
+
+func TestMemoryOutputByName(t *testing.T) {
+	logger, err := LoggerFromConfigAsString(`
+	<seelog type="sync">
+		<outputs formatid="lvl">
+			<filter levels="error">
+				<memory name="errors" capacity="10"/>
+			</filter>
+			<memory name="all" capacity="10"/>
+		</outputs>
+		<formats>
+			<format id="lvl" format="%l %Msg"/>
+		</formats>
+	</seelog>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logger.Info("info")
+	logger.Error("error")
+
+	errors, err := MemoryOutputByName(logger, "errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := errors.Snapshot(nil)
+	if len(records) != 1 || records[0].Formatted != "e error" {
+		t.Errorf("unexpected records in 'errors': %v", records)
+	}
+
+	all, err := MemoryOutputByName(logger, "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Len() != 2 || all.Capacity() != 10 {
+		t.Errorf("unexpected 'all' state: len=%d, capacity=%d", all.Len(), all.Capacity())
+	}
+
+	if _, err := MemoryOutputByName(logger, "missing"); err == nil {
+		t.Error("expected error for unknown memory output")
+	}
+}
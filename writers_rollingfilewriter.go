@@ -53,6 +53,7 @@ type rollingType uint8
 const (
 	rollingTypeSize = iota
 	rollingTypeTime
+	rollingTypeDateSize
 )
 
 // Types of the rolled file naming mode: prefix, postfix, etc.
@@ -79,8 +80,9 @@ func rollingNameModeFromString(rollingNameStr string) (rollingNameMode, bool) {
 }
 
 var rollingTypesStringRepresentation = map[rollingType]string{
-	rollingTypeSize: "size",
-	rollingTypeTime: "date",
+	rollingTypeSize:     "size",
+	rollingTypeTime:     "date",
+	rollingTypeDateSize: "datesize",
 }
 
 func rollingTypeFromString(rollingTypeStr string) (rollingType, bool) {
@@ -100,6 +102,8 @@ const (
 	rollingArchiveNone = iota
 	rollingArchiveZip
 	rollingArchiveGzip
+
+	maxRollingArchiveType = 255
 )
 
 var rollingArchiveTypesStringRepresentation = map[rollingArchiveType]string{
@@ -108,7 +112,31 @@ var rollingArchiveTypesStringRepresentation = map[rollingArchiveType]string{
 	rollingArchiveGzip: "gzip",
 }
 
-type archiver func(f *os.File, exploded bool) archive.WriteCloser
+// Default compression level of archives: the default level of the archive format.
+const rollingArchiveDefaultLevel = -1
+
+// ArchiveFormat describes an archive format which may be registered with RegisterArchiveType
+// and then used as the 'archivetype' of rolling file writers.
+type ArchiveFormat struct {
+	// Extension is appended to archive file names, e.g. ".zst".
+	Extension string
+
+	// MultipleEntries is true if an archive may contain several files, as zip does.
+	// Otherwise, as with gzip, several files are first put into a tar archive.
+	MultipleEntries bool
+
+	// NewWriter creates an archive writer. Level is the compression level set by the
+	// 'archivelevel' attribute, -1 means the default level of the format.
+	// archive.NewSingleFileWriter may be used to wrap stream compressors.
+	NewWriter func(w io.Writer, level int) (archive.WriteCloser, error)
+
+	// NewReader opens an existing archive so that new files can be appended to it.
+	// Closing the returned reader must not close f.
+	// archive.NewSingleFileReader may be used to wrap stream decompressors.
+	NewReader func(f *os.File) (archive.ReadCloser, error)
+}
+
+type archiver func(w io.Writer, exploded bool, level int) (archive.WriteCloser, error)
 
 type unarchiver func(f *os.File) (archive.ReadCloser, error)
 
@@ -119,14 +147,58 @@ type compressionType struct {
 	unarchiver            unarchiver
 }
 
+func newCompressionType(format ArchiveFormat) compressionType {
+	ct := compressionType{
+		extension:             format.Extension,
+		handleMultipleEntries: format.MultipleEntries,
+	}
+	ct.archiver = func(w io.Writer, exploded bool, level int) (archive.WriteCloser, error) {
+		aw, err := format.NewWriter(w, level)
+		if err != nil {
+			return nil, err
+		}
+		if exploded || format.MultipleEntries {
+			return aw, nil
+		}
+		return tar.NewWriteMultiCloser(aw, aw), nil
+	}
+	ct.unarchiver = func(f *os.File) (archive.ReadCloser, error) {
+		ar, err := format.NewReader(f)
+		if err != nil || format.MultipleEntries {
+			return ar, err
+		}
+
+		// Determine if the archive is a tar
+		tr := tar.NewReader(ar)
+		_, err = tr.Next()
+		isTar := err == nil
+		ar.Close()
+
+		// Reset to beginning of file
+		if _, err := f.Seek(0, os.SEEK_SET); err != nil {
+			return nil, err
+		}
+		ar, err = format.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if isTar {
+			return archive.NopCloser(tar.NewReader(ar)), nil
+		}
+		return ar, nil
+	}
+	return ct
+}
+
 var compressionTypes = map[rollingArchiveType]compressionType{
-	rollingArchiveZip: {
-		extension:             ".zip",
-		handleMultipleEntries: true,
-		archiver: func(f *os.File, _ bool) archive.WriteCloser {
-			return zip.NewWriter(f)
+	rollingArchiveZip: newCompressionType(ArchiveFormat{
+		Extension:       ".zip",
+		MultipleEntries: true,
+		NewWriter: func(w io.Writer, level int) (archive.WriteCloser, error) {
+			return zip.NewWriterLevel(w, level)
 		},
-		unarchiver: func(f *os.File) (archive.ReadCloser, error) {
+		NewReader: func(f *os.File) (archive.ReadCloser, error) {
 			fi, err := f.Stat()
 			if err != nil {
 				return nil, err
@@ -137,40 +209,48 @@ var compressionTypes = map[rollingArchiveType]compressionType{
 			}
 			return archive.NopCloser(r), nil
 		},
-	},
-	rollingArchiveGzip: {
-		extension:             ".gz",
-		handleMultipleEntries: false,
-		archiver: func(f *os.File, exploded bool) archive.WriteCloser {
-			gw := gzip.NewWriter(f)
-			if exploded {
-				return gw
-			}
-			return tar.NewWriteMultiCloser(gw, gw)
+	}),
+	rollingArchiveGzip: newCompressionType(ArchiveFormat{
+		Extension:       ".gz",
+		MultipleEntries: false,
+		NewWriter: func(w io.Writer, level int) (archive.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
 		},
-		unarchiver: func(f *os.File) (archive.ReadCloser, error) {
-			gr, err := gzip.NewReader(f, f.Name())
-			if err != nil {
-				return nil, err
-			}
-
-			// Determine if the gzip is a tar
-			tr := tar.NewReader(gr)
-			_, err = tr.Next()
-			isTar := err == nil
+		NewReader: func(f *os.File) (archive.ReadCloser, error) {
+			return gzip.NewReader(f, f.Name())
+		},
+	}),
+}
 
-			// Reset to beginning of file
-			if _, err := f.Seek(0, os.SEEK_SET); err != nil {
-				return nil, err
-			}
-			gr.Reset(f)
+// RegisterArchiveType makes an archive format available under the name in the
+// 'archivetype' attribute of rolling file writers. It is intended to be called
+// from init funcs and panics if the name is already used or the format is incomplete.
+func RegisterArchiveType(name string, format ArchiveFormat) {
+	if _, ok := rollingArchiveTypeFromString(name); ok {
+		panic(fmt.Sprintf("duplicate archive type: %s", name))
+	}
+	if len(format.Extension) == 0 || format.NewWriter == nil || format.NewReader == nil {
+		panic(fmt.Sprintf("archive type %s must have an extension, a writer and a reader", name))
+	}
+	if len(rollingArchiveTypesStringRepresentation) > maxRollingArchiveType {
+		panic("too many archive types")
+	}
+	archiveType := rollingArchiveType(len(rollingArchiveTypesStringRepresentation))
+	rollingArchiveTypesStringRepresentation[archiveType] = name
+	compressionTypes[archiveType] = newCompressionType(format)
+}
 
-			if isTar {
-				return archive.NopCloser(tar.NewReader(gr)), nil
-			}
-			return gr, nil
-		},
-	},
+// checkArchiveLevel returns an error if the archive type does not support the level.
+func checkArchiveLevel(archiveType rollingArchiveType, level int) error {
+	ct, ok := compressionTypes[archiveType]
+	if !ok {
+		return fmt.Errorf("archive type %v does not support compression levels", archiveType)
+	}
+	w, err := ct.archiver(ioutil.Discard, true, level)
+	if err != nil {
+		return err
+	}
+	return w.Close()
 }
 
 func (compressionType *compressionType) rollingArchiveTypeName(name string, exploded bool) string {
@@ -195,6 +275,9 @@ func rollingArchiveTypeFromString(rollingArchiveTypeStr string) (rollingArchiveT
 // Default names for different archive types
 var rollingArchiveDefaultExplodedName = "old"
 
+// Default size of the background archiving queue
+const rollingArchiveDefaultQueueSize = 16
+
 func rollingArchiveTypeDefaultName(archiveType rollingArchiveType, exploded bool) (string, error) {
 	compressionType, ok := compressionTypes[archiveType]
 	if !ok {
@@ -203,6 +286,57 @@ func rollingArchiveTypeDefaultName(archiveType rollingArchiveType, exploded bool
 	return compressionType.rollingArchiveTypeName("log", exploded), nil
 }
 
+// RollEventType is the kind of a rolling file writer event.
+type RollEventType uint8
+
+const (
+	// RollEventRolled is sent after the current file is renamed to a new roll.
+	RollEventRolled RollEventType = iota
+	// RollEventArchived is sent after an archive is written.
+	RollEventArchived
+	// RollEventDeleted is sent after a roll or an archive is deleted.
+	RollEventDeleted
+)
+
+var rollEventTypeToStringMap = map[RollEventType]string{
+	RollEventRolled:   "rolled",
+	RollEventArchived: "archived",
+	RollEventDeleted:  "deleted",
+}
+
+func (eventType RollEventType) String() string {
+	return rollEventTypeToStringMap[eventType]
+}
+
+// RollEvent describes a change of the files of a rolling file writer.
+type RollEvent struct {
+	Type    RollEventType
+	LogFile string    // Path of the writer log file, as configured
+	Path    string    // Path of the new roll, of the written archive or of the deleted file
+	Sources []string  // For RollEventArchived, paths of the rolls put into the archive
+	Time    time.Time // Writer clock time of the event
+}
+
+// RollHook is called by rolling file writers on roll events. Hooks are called one by one,
+// in the order of events, on a goroutine of the writer, so that slow hooks do not hold writes
+// or archiving. Close waits until the hooks of all events are done.
+//
+// A hook owns the roll of RollEventRolled and the archive of RollEventArchived: it may
+// remove or move them, e.g. after uploading them. The writer skips rolls which are gone
+// when it archives or deletes them, and does not count them in the history any more.
+type RollHook func(event RollEvent)
+
+var registeredRollHooks = make(map[string]RollHook)
+
+// RegisterRollHook makes a roll hook available under the name in the 'hooks' attribute of
+// rolling file writers. It is intended to be called from init funcs and panics on duplicate names.
+func RegisterRollHook(name string, hook RollHook) {
+	if _, ok := registeredRollHooks[name]; ok {
+		panic(fmt.Sprintf("duplicate roll hook: %s", name))
+	}
+	registeredRollHooks[name] = hook
+}
+
 // rollerVirtual is an interface that represents all virtual funcs that are
 // called in different rolling writer subtypes.
 type rollerVirtual interface {
@@ -233,11 +367,41 @@ type rollingFileWriter struct {
 	archiveType     rollingArchiveType
 	archivePath     string
 	archiveExploded bool
+	archiveLevel    int
 	fullName        bool
 	maxRolls        int
 	nameMode        rollingNameMode
 	self            rollerVirtual // Used for virtual calls
 	rollLock        sync.Mutex
+	clock           Clock
+	maxAge          time.Duration        // Rolls and archives older than maxAge are removed. 0 - no limit
+	maxTotalSize    int64                // Rolls and archives are removed to fit in maxTotalSize bytes. 0 - no limit
+	archiveQueue    *rollingArchiveQueue // Background archiving queue. nil - archive inline
+	recovered       bool                 // Left-over rolls were checked after start
+	reopenInterval  time.Duration        // Period of checks whether the file was moved or removed. 0 - no checks
+	lastCheck       time.Time
+	symlinkPath     string // Symlink to the current file. Empty - no symlink
+	hooks           []rollHookEntry
+	hooksLock       sync.Mutex
+	hookQueue       rollHookQueue // Events waiting for the hooks
+	processLock     *processLock  // Lock shared with other processes. nil - no locking
+	syncing         syncState
+}
+
+type rollHookEntry struct {
+	name string
+	hook RollHook
+}
+
+// rollStateResetter is implemented by rolling writers which keep a roll state apart from
+// the file, to reset it when another process rolled the file.
+type rollStateResetter interface {
+	resetRollState()
+}
+
+// rollTimeParser is implemented by rolling writers which roll names contain the roll time.
+type rollTimeParser interface {
+	rollTime(rname string) (time.Time, bool)
 }
 
 func newRollingFileWriter(fpath string, rtype rollingType, atype rollingArchiveType, apath string, maxr int, namemode rollingNameMode,
@@ -254,10 +418,492 @@ func newRollingFileWriter(fpath string, rtype rollingType, atype rollingArchiveT
 	rw.nameMode = namemode
 	rw.maxRolls = maxr
 	rw.archiveExploded = archiveExploded
+	rw.archiveLevel = rollingArchiveDefaultLevel
 	rw.fullName = fullName
+	rw.clock = SystemClock
 	return rw, nil
 }
 
+// SetClock sets the clock used to decide when the writer rolls.
+func (rw *rollingFileWriter) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	rw.clock = clock
+}
+
+// SetRetention sets additional limits for the roll history. Rolls older than maxAge
+// are archived (if archiving is on) and removed; the oldest rolls are archived and removed
+// until the rolls and archives fit in maxTotalSize bytes. The age of a roll is taken from
+// its name for date based writers, from its modification time otherwise.
+//
+// Archives are subject to the same limits, their age is counted from the time of archiving.
+// Exploded archives are removed one by one, a single archive file is removed as a whole.
+// Zero values turn the corresponding limit off.
+func (rw *rollingFileWriter) SetRetention(maxAge time.Duration, maxTotalSize int64) {
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	rw.maxAge = maxAge
+	rw.maxTotalSize = maxTotalSize
+}
+
+func (rw *rollingFileWriter) hasRetention() bool {
+	return rw.maxAge > 0 || rw.maxTotalSize > 0
+}
+
+func (rw *rollingFileWriter) optionsString() string {
+	str := ""
+	if rw.hasRetention() {
+		str += fmt.Sprintf(", maxAge: %v, maxTotalSize: %v", rw.maxAge, rw.maxTotalSize)
+	}
+	if rw.archiveQueue != nil {
+		str += fmt.Sprintf(", archiveQueue: %d", rw.archiveQueue.maxSize)
+	}
+	if rw.archiveLevel != rollingArchiveDefaultLevel {
+		str += fmt.Sprintf(", archiveLevel: %d", rw.archiveLevel)
+	}
+	if rw.reopenInterval > 0 {
+		str += fmt.Sprintf(", reopenInterval: %v", rw.reopenInterval)
+	}
+	if len(rw.symlinkPath) != 0 {
+		str += fmt.Sprintf(", symlink: %s", rw.symlinkPath)
+	}
+	if rw.processLock != nil {
+		str += ", locked"
+	}
+	if rw.syncing.policy.Mode != SyncNever {
+		str += fmt.Sprintf(", sync: %s", rw.syncing.policy)
+	}
+	var hookNames []string
+	for _, entry := range rw.hooks {
+		if len(entry.name) != 0 {
+			hookNames = append(hookNames, entry.name)
+		}
+	}
+	if len(hookNames) != 0 {
+		str += fmt.Sprintf(", hooks: %s", strings.Join(hookNames, ","))
+	}
+	return str
+}
+
+// AddRollHook adds a hook called on every roll, archive and deletion of the writer.
+// The hook has no name, so LoggerConfigAsString fails for loggers with the writer;
+// hooks registered with RegisterRollHook and set in the 'hooks' attribute have names.
+func (rw *rollingFileWriter) AddRollHook(hook RollHook) {
+	rw.addRollHook("", hook)
+}
+
+func (rw *rollingFileWriter) addRollHook(name string, hook RollHook) {
+	rw.hooksLock.Lock()
+	defer rw.hooksLock.Unlock()
+	rw.hooks = append(rw.hooks, rollHookEntry{name, hook})
+}
+
+func (rw *rollingFileWriter) fireRollEvent(eventType RollEventType, path string, sources []string) {
+	rw.hooksLock.Lock()
+	hooks := rw.hooks
+	rw.hooksLock.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	event := RollEvent{
+		Type:    eventType,
+		LogFile: filepath.Join(rw.currentDirPath, rw.fileName),
+		Path:    path,
+		Sources: sources,
+		Time:    rw.clock.Now(),
+	}
+	rw.hookQueue.push(event, hooks)
+}
+
+func callRollHook(hook RollHook, event RollEvent) {
+	defer func() {
+		if err := recover(); err != nil {
+			reportInternalError(fmt.Errorf("recovered from panic in roll hook: %v", err))
+		}
+	}()
+	hook(event)
+}
+
+// rollHookCall is a roll event with the hooks registered when it happened.
+type rollHookCall struct {
+	event RollEvent
+	hooks []rollHookEntry
+}
+
+// rollHookQueue calls roll hooks in a background goroutine. As with rollingArchiveQueue,
+// the goroutine is started when an event is pushed to an empty queue and exits once
+// the queue is empty.
+type rollHookQueue struct {
+	calls   []rollHookCall
+	running bool
+	m       sync.Mutex
+	cond    *sync.Cond
+}
+
+func (queue *rollHookQueue) push(event RollEvent, hooks []rollHookEntry) {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	queue.calls = append(queue.calls, rollHookCall{event, hooks})
+	if !queue.running {
+		queue.running = true
+		go queue.process()
+	}
+}
+
+func (queue *rollHookQueue) process() {
+	queue.m.Lock()
+	for len(queue.calls) != 0 {
+		call := queue.calls[0]
+		queue.calls = queue.calls[1:]
+		queue.m.Unlock()
+
+		for _, entry := range call.hooks {
+			callRollHook(entry.hook, call.event)
+		}
+
+		queue.m.Lock()
+	}
+	queue.running = false
+	if queue.cond != nil {
+		queue.cond.Broadcast()
+	}
+	queue.m.Unlock()
+}
+
+// wait blocks until the hooks of all pushed events are done.
+func (queue *rollHookQueue) wait() {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	if queue.cond == nil {
+		queue.cond = sync.NewCond(&queue.m)
+	}
+	for queue.running {
+		queue.cond.Wait()
+	}
+}
+
+// SetSymlink makes the writer keep a symlink at path which points to the current file.
+// A relative path is relative to the directory of the log file. The link is replaced
+// atomically whenever a new current file is opened. An empty path turns the link off.
+func (rw *rollingFileWriter) SetSymlink(path string) error {
+	if len(path) != 0 && !filepath.IsAbs(path) {
+		path = filepath.Join(rw.currentDirPath, path)
+	}
+	if len(path) != 0 && filepath.Clean(path) == filepath.Join(rw.currentDirPath, rw.fileName) &&
+		rw.self.getCurrentFileName() == rw.fileName {
+		return fmt.Errorf("symlink %s can not replace the log file", path)
+	}
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	rw.symlinkPath = path
+	return nil
+}
+
+// updateSymlink points the symlink to the current file. The link is created
+// under a temporary name and then renamed, so readers never see it missing.
+func (rw *rollingFileWriter) updateSymlink() {
+	if len(rw.symlinkPath) == 0 {
+		return
+	}
+
+	target := filepath.Join(rw.currentDirPath, rw.currentName)
+	if filepath.Dir(rw.symlinkPath) == filepath.Clean(rw.currentDirPath) {
+		target = rw.currentName
+	} else if absTarget, err := filepath.Abs(target); err == nil {
+		target = absTarget
+	}
+	if current, err := os.Readlink(rw.symlinkPath); err == nil && current == target {
+		return
+	}
+
+	tmpPath := fmt.Sprintf("%s.%d.tmp", rw.symlinkPath, os.Getpid())
+	os.Remove(tmpPath)
+	if err := os.Symlink(target, tmpPath); err != nil {
+		reportInternalError(fmt.Errorf("cannot create symlink to %s: %s", target, err))
+		return
+	}
+	if err := os.Rename(tmpPath, rw.symlinkPath); err != nil {
+		os.Remove(tmpPath)
+		reportInternalError(fmt.Errorf("cannot update symlink %s: %s", rw.symlinkPath, err))
+	}
+}
+
+// SetReopenInterval makes the writer check every interval whether the current file was moved
+// or removed, e.g. by logrotate, and reopen the file by its name if so. 0 turns the checks off.
+func (rw *rollingFileWriter) SetReopenInterval(interval time.Duration) {
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	rw.reopenInterval = interval
+}
+
+// Reopen closes the current file and opens it by its name again.
+func (rw *rollingFileWriter) Reopen() error {
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	return rw.reopen()
+}
+
+func (rw *rollingFileWriter) reopen() error {
+	if rw.currentFile == nil {
+		return nil
+	}
+	if err := rw.currentFile.Close(); err != nil {
+		reportInternalError(err)
+	}
+	rw.currentFile = nil
+	return rw.createFileAndFolderIfNeeded(false)
+}
+
+func (rw *rollingFileWriter) needsToReopen() bool {
+	if rw.reopenInterval <= 0 {
+		return false
+	}
+	now := rw.clock.Now()
+	if now.Sub(rw.lastCheck) < rw.reopenInterval {
+		return false
+	}
+	rw.lastCheck = now
+	return fileReplaced(rw.currentFile, filepath.Join(rw.currentDirPath, rw.currentName))
+}
+
+// SetLocking makes the writer share the log file with other processes: writes, rolls,
+// archiving and removal of old rolls hold an advisory lock (flock) of the file, and the
+// current file is reopened after another process has rolled it. Old rolls are archived inline
+// while locked, even if SetArchiveAsync was called. Locking is only supported on Linux.
+func (rw *rollingFileWriter) SetLocking(enabled bool) error {
+	var lock *processLock
+	if enabled {
+		var err error
+		if lock, err = newProcessLock(filepath.Join(rw.currentDirPath, rw.fileName)); err != nil {
+			return err
+		}
+	}
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	if rw.processLock != nil {
+		rw.processLock.Close()
+	}
+	rw.processLock = lock
+	return nil
+}
+
+// SetSyncPolicy sets when the written data is synced to the storage.
+func (rw *rollingFileWriter) SetSyncPolicy(policy SyncPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	rw.syncing.policy = policy
+	return nil
+}
+
+// Flush syncs the current file, unless the sync policy is SyncNever.
+func (rw *rollingFileWriter) Flush() {
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	if err := rw.syncing.beforeClose(rw.currentFile); err != nil {
+		reportInternalError(err)
+	}
+}
+
+func (rw *rollingFileWriter) syncsOnLevel(level LogLevel) bool {
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	return rw.syncing.syncsOnLevel(level)
+}
+
+func (rw *rollingFileWriter) syncData() error {
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	if rw.currentFile == nil {
+		return nil
+	}
+	rw.syncing.lastSync = rw.clock.Now()
+	return rw.currentFile.Sync()
+}
+
+// syncWithOtherProcesses must be called under the process lock before checking whether
+// the file needs to roll. It reopens the file if another process rolled it, and updates
+// its size with the writes of other processes otherwise.
+func (rw *rollingFileWriter) syncWithOtherProcesses() error {
+	if rw.currentFile == nil {
+		return nil
+	}
+	if fileReplaced(rw.currentFile, filepath.Join(rw.currentDirPath, rw.currentName)) {
+		if resetter, ok := rw.self.(rollStateResetter); ok {
+			resetter.resetRollState()
+		}
+		return rw.reopen()
+	}
+	stat, err := rw.currentFile.Stat()
+	if err != nil {
+		return err
+	}
+	rw.currentFileSize = stat.Size()
+	return nil
+}
+
+// SetArchiveLevel sets the compression level of archives. Valid levels depend on
+// the archive type, -1 means the default level.
+func (rw *rollingFileWriter) SetArchiveLevel(level int) error {
+	if level != rollingArchiveDefaultLevel {
+		if err := checkArchiveLevel(rw.archiveType, level); err != nil {
+			return err
+		}
+	}
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	rw.archiveLevel = level
+	return nil
+}
+
+// SetArchiveAsync moves archiving of old rolls to a background goroutine, so that
+// writes are not delayed by compression. At most queueSize archive jobs may wait in the
+// queue, further rolls block until there is space. A queueSize <= 0 makes archiving inline again.
+//
+// Rolls waiting for archiving are left on disk until archived, so if the process
+// stops before that, they are archived after the next start.
+func (rw *rollingFileWriter) SetArchiveAsync(queueSize int) {
+	rw.WaitArchives()
+	rw.rollLock.Lock()
+	defer rw.rollLock.Unlock()
+	if queueSize <= 0 {
+		rw.archiveQueue = nil
+		return
+	}
+	rw.archiveQueue = newRollingArchiveQueue(queueSize)
+}
+
+// WaitArchives blocks until all queued archive jobs and the roll hooks of their events are done.
+func (rw *rollingFileWriter) WaitArchives() {
+	rw.rollLock.Lock()
+	queue := rw.archiveQueue
+	rw.rollLock.Unlock()
+	if queue != nil {
+		queue.wait()
+	}
+	rw.hookQueue.wait()
+}
+
+// fileTime returns the time of a roll or an archive: the time encoded in the roll name
+// if the writer supports it, the modification time otherwise.
+func (rw *rollingFileWriter) fileTime(name string, fi os.FileInfo) time.Time {
+	if parser, ok := rw.self.(rollTimeParser); ok && rw.hasRollName(name) {
+		if t, ok := parser.rollTime(rw.getFileRollName(name)); ok {
+			return t
+		}
+	}
+	return fi.ModTime()
+}
+
+// rollsToDelete returns the number of the oldest rolls in history that exceed the limits.
+func (rw *rollingFileWriter) rollsToDelete(history []string) int {
+	count := 0
+	if rw.maxRolls > 0 && len(history) > rw.maxRolls {
+		count = len(history) - rw.maxRolls
+	}
+	if !rw.hasRetention() {
+		return count
+	}
+
+	now := rw.clock.Now()
+	var totalSize int64
+	for i := len(history) - 1; i >= count; i-- {
+		fi, err := os.Stat(filepath.Join(rw.currentDirPath, history[i]))
+		if err != nil {
+			continue
+		}
+		totalSize += fi.Size()
+		expired := rw.maxAge > 0 && now.Sub(rw.fileTime(history[i], fi)) > rw.maxAge
+		oversized := rw.maxTotalSize > 0 && totalSize > rw.maxTotalSize
+		if expired || oversized {
+			return i + 1
+		}
+	}
+	return count
+}
+
+// historySize returns the total size of the rolls in history.
+func (rw *rollingFileWriter) historySize(history []string) int64 {
+	var size int64
+	for _, name := range history {
+		if fi, err := os.Stat(filepath.Join(rw.currentDirPath, name)); err == nil {
+			size += fi.Size()
+		}
+	}
+	return size
+}
+
+type archiveFile struct {
+	path string
+	size int64
+	time time.Time
+}
+
+// archiveFiles returns the existing archives of the writer sorted from the oldest to the newest.
+func (rw *rollingFileWriter) archiveFiles() []archiveFile {
+	var files []archiveFile
+	if !rw.archiveExploded {
+		if fi, err := os.Stat(rw.archivePath); err == nil {
+			files = append(files, archiveFile{rw.archivePath, fi.Size(), fi.ModTime()})
+		}
+		return files
+	}
+
+	extension := compressionTypes[rw.archiveType].extension
+	names, err := getDirFilePaths(rw.archivePath, nil, true)
+	if err != nil {
+		return nil
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, extension) {
+			continue
+		}
+		if !rw.hasRollName(strings.TrimSuffix(name, extension)) {
+			continue
+		}
+		path := filepath.Join(rw.archivePath, name)
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, archiveFile{path, fi.Size(), fi.ModTime()})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].time.Before(files[j].time) })
+	return files
+}
+
+// deleteOldArchives removes archives that are older than maxAge or that do not fit in
+// the part of maxTotalSize left after the rolls, which take historySize bytes.
+func (rw *rollingFileWriter) deleteOldArchives(historySize int64, now time.Time) {
+	if rw.archiveType == rollingArchiveNone {
+		return
+	}
+
+	files := rw.archiveFiles()
+	var totalSize int64
+	for _, file := range files {
+		totalSize += file.size
+	}
+
+	for _, file := range files {
+		expired := rw.maxAge > 0 && now.Sub(file.time) > rw.maxAge
+		oversized := rw.maxTotalSize > 0 && historySize+totalSize > rw.maxTotalSize
+		if !expired && !oversized {
+			continue
+		}
+		if !rw.removeFile(file.path) {
+			continue
+		}
+		totalSize -= file.size
+	}
+}
+
 func (rw *rollingFileWriter) hasRollName(file string) bool {
 	switch rw.nameMode {
 	case rollingNameModePostfix:
@@ -305,6 +951,21 @@ func (rw *rollingFileWriter) getSortedLogHistory() ([]string, error) {
 	return validSortedFiles, nil
 }
 
+// unqueuedRolls returns the rolls of the history which are not queued for archiving.
+// Queued rolls still take their names, but may not be selected for deletion again.
+func (rw *rollingFileWriter) unqueuedRolls(history []string) []string {
+	if rw.archiveQueue == nil {
+		return history
+	}
+	var rolls []string
+	for _, roll := range history {
+		if !rw.archiveQueue.isQueued(roll) {
+			rolls = append(rolls, roll)
+		}
+	}
+	return rolls
+}
+
 func (rw *rollingFileWriter) createFileAndFolderIfNeeded(first bool) error {
 	var err error
 
@@ -333,6 +994,8 @@ func (rw *rollingFileWriter) createFileAndFolderIfNeeded(first bool) error {
 	}
 
 	rw.currentFileSize = stat.Size()
+	rw.lastCheck = rw.clock.Now()
+	rw.updateSymlink()
 	return nil
 }
 
@@ -345,6 +1008,9 @@ func (rw *rollingFileWriter) archiveExplodedLogs(logFilename string, compression
 
 	rollPath := filepath.Join(rw.currentDirPath, logFilename)
 	src, err := os.Open(rollPath)
+	if os.IsNotExist(err) {
+		return nil // Taken by a roll hook
+	}
 	if err != nil {
 		return err
 	}
@@ -364,12 +1030,18 @@ func (rw *rollingFileWriter) archiveExplodedLogs(logFilename string, compression
 		}
 
 		// Finalize archive by swapping the buffered archive into place
-		err = os.Rename(dst.Name(), filepath.Join(rw.archivePath,
-			compressionType.rollingArchiveTypeName(logFilename, true)))
+		archivePath := filepath.Join(rw.archivePath, compressionType.rollingArchiveTypeName(logFilename, true))
+		err = os.Rename(dst.Name(), archivePath)
+		if err == nil {
+			rw.fireRollEvent(RollEventArchived, archivePath, []string{rollPath})
+		}
 	}()
 
 	// archive entry
-	w := compressionType.archiver(dst, true)
+	w, err := compressionType.archiver(dst, true, rw.archiveLevel)
+	if err != nil {
+		return err
+	}
 	defer closeWithError(w)
 	fi, err := src.Stat()
 	if err != nil {
@@ -395,18 +1067,25 @@ func (rw *rollingFileWriter) archiveUnexplodedLogs(compressionType compressionTy
 	if err != nil {
 		return err
 	}
+	var sources []string
 	defer func() {
 		closeWithError(dst)
-		if err != nil {
+		if err != nil || len(sources) == 0 {
 			os.Remove(dst.Name()) // Can't do anything when we fail to remove temp file
 			return
 		}
 
 		// Finalize archive by moving the buffered archive into place
 		err = os.Rename(dst.Name(), rw.archivePath)
+		if err == nil {
+			rw.fireRollEvent(RollEventArchived, rw.archivePath, sources)
+		}
 	}()
 
-	w := compressionType.archiver(dst, false)
+	w, err := compressionType.archiver(dst, false, rw.archiveLevel)
+	if err != nil {
+		return err
+	}
 	defer closeWithError(w)
 
 	src, err := os.Open(rw.archivePath)
@@ -434,6 +1113,9 @@ func (rw *rollingFileWriter) archiveUnexplodedLogs(compressionType compressionTy
 	for i := 0; i < rollsToDelete; i++ {
 		rollPath := filepath.Join(rw.currentDirPath, history[i])
 		src, err := os.Open(rollPath)
+		if os.IsNotExist(err) {
+			continue // Taken by a roll hook
+		}
 		if err != nil {
 			return err
 		}
@@ -448,45 +1130,103 @@ func (rw *rollingFileWriter) archiveUnexplodedLogs(compressionType compressionTy
 		if _, err := io.Copy(w, src); err != nil {
 			return err
 		}
+		sources = append(sources, rollPath)
 	}
 	return nil
 }
 
-func (rw *rollingFileWriter) deleteOldRolls(history []string) error {
-	if rw.maxRolls <= 0 {
-		return nil
-	}
-
-	rollsToDelete := len(history) - rw.maxRolls
+func (rw *rollingFileWriter) deleteOldRolls(history []string, rollsToDelete int) error {
 	if rollsToDelete <= 0 {
 		return nil
 	}
 
-	if rw.archiveType != rollingArchiveNone {
-		if rw.archiveExploded {
-			os.MkdirAll(rw.archivePath, defaultDirectoryPermissions)
+	rw.archiveRolls(history[:rollsToDelete])
+	rw.removeRolls(history[:rollsToDelete])
+	return nil
+}
 
-			// Archive logs
-			for i := 0; i < rollsToDelete; i++ {
-				rw.archiveExplodedLogs(history[i], compressionTypes[rw.archiveType])
+// archiveRolls archives the rolls if archiving is on.
+func (rw *rollingFileWriter) archiveRolls(rolls []string) {
+	if rw.archiveType == rollingArchiveNone {
+		return
+	}
+	if rw.archiveExploded {
+		os.MkdirAll(rw.archivePath, defaultDirectoryPermissions)
+
+		// Archive logs
+		for _, roll := range rolls {
+			if err := rw.archiveExplodedLogs(roll, compressionTypes[rw.archiveType]); err != nil {
+				reportInternalError(err)
 			}
-		} else {
-			os.MkdirAll(filepath.Dir(rw.archivePath), defaultDirectoryPermissions)
+		}
+	} else {
+		os.MkdirAll(filepath.Dir(rw.archivePath), defaultDirectoryPermissions)
 
-			rw.archiveUnexplodedLogs(compressionTypes[rw.archiveType], rollsToDelete, history)
+		if err := rw.archiveUnexplodedLogs(compressionTypes[rw.archiveType], len(rolls), rolls); err != nil {
+			reportInternalError(err)
 		}
 	}
+}
 
-	var err error
+func (rw *rollingFileWriter) removeRolls(rolls []string) {
 	// In all cases (archive files or not) the files should be deleted.
-	for i := 0; i < rollsToDelete; i++ {
+	for _, roll := range rolls {
 		// Try best to delete files without breaking the loop.
-		if err = tryRemoveFile(filepath.Join(rw.currentDirPath, history[i])); err != nil {
-			reportInternalError(err)
-		}
+		rw.removeFile(filepath.Join(rw.currentDirPath, roll))
 	}
+}
 
-	return nil
+// removeFile removes a roll or an archive, reporting errors. Files that are already
+// removed, e.g. by a roll hook, are skipped.
+func (rw *rollingFileWriter) removeFile(path string) bool {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return true
+	}
+	if err != nil {
+		reportInternalError(err)
+		return false
+	}
+	rw.fireRollEvent(RollEventDeleted, path, nil)
+	return true
+}
+
+// deleteOldRollsAsync queues the old rolls for background archiving.
+func (rw *rollingFileWriter) deleteOldRollsAsync(history []string, rollsToDelete int) {
+	job := &rollingArchiveJob{
+		writer:      rw,
+		rolls:       append([]string(nil), history[:rollsToDelete]...),
+		historySize: -1,
+		now:         rw.clock.Now(),
+	}
+	if rw.hasRetention() {
+		job.historySize = rw.historySize(history[rollsToDelete:])
+	}
+	rw.archiveQueue.push(job)
+}
+
+// archivesAsync returns true if old rolls are archived in background. With process locking
+// they are archived inline, as other processes do not know about the queued rolls.
+func (rw *rollingFileWriter) archivesAsync() bool {
+	return rw.archiveQueue != nil && rw.archiveType != rollingArchiveNone && rw.processLock == nil
+}
+
+// recoverOldRolls queues the rolls which exceed the limits, but were not archived
+// because the process stopped before the background archiving was done.
+func (rw *rollingFileWriter) recoverOldRolls() {
+	rw.recovered = true
+	if !rw.archivesAsync() {
+		return
+	}
+	history, err := rw.getSortedLogHistory()
+	if err != nil {
+		reportInternalError(err)
+		return
+	}
+	history = rw.unqueuedRolls(history)
+	if rollsToDelete := rw.rollsToDelete(history); rollsToDelete > 0 {
+		rw.deleteOldRollsAsync(history, rollsToDelete)
+	}
 }
 
 func (rw *rollingFileWriter) getFileRollName(fileName string) string {
@@ -501,6 +1241,9 @@ func (rw *rollingFileWriter) getFileRollName(fileName string) string {
 
 func (rw *rollingFileWriter) roll() error {
 	// First, close current file.
+	if err := rw.syncing.beforeClose(rw.currentFile); err != nil {
+		return err
+	}
 	err := rw.currentFile.Close()
 	if err != nil {
 		return err
@@ -538,16 +1281,24 @@ func (rw *rollingFileWriter) roll() error {
 	if err != nil {
 		return err
 	}
+	rw.fireRollEvent(RollEventRolled, filepath.Join(rw.currentDirPath, newHistoryName), nil)
 
 	// Finally, add the newly added history file to the history archive
 	// and, if after that the archive exceeds the allowed max limit, older rolls
-	// must the removed/archived.
-	history = append(history, newHistoryName)
-	if len(history) > rw.maxRolls {
-		err = rw.deleteOldRolls(history)
-		if err != nil {
-			return err
-		}
+	// must the removed/archived. Archives are then checked against retention limits.
+	// Rolls already queued for archiving are left to the background worker.
+	history = append(rw.unqueuedRolls(history), newHistoryName)
+	rollsToDelete := rw.rollsToDelete(history)
+	if rw.archivesAsync() && rollsToDelete > 0 {
+		rw.deleteOldRollsAsync(history, rollsToDelete)
+		return nil
+	}
+	err = rw.deleteOldRolls(history, rollsToDelete)
+	if err != nil {
+		return err
+	}
+	if rw.hasRetention() {
+		rw.deleteOldArchives(rw.historySize(history[rollsToDelete:]), rw.clock.Now())
 	}
 
 	return nil
@@ -557,6 +1308,22 @@ func (rw *rollingFileWriter) Write(bytes []byte) (n int, err error) {
 	rw.rollLock.Lock()
 	defer rw.rollLock.Unlock()
 
+	if rw.processLock != nil {
+		if err := rw.processLock.Lock(); err != nil {
+			return 0, err
+		}
+		defer rw.processLock.Unlock()
+		if err := rw.syncWithOtherProcesses(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rw.currentFile != nil && rw.needsToReopen() {
+		if err := rw.reopen(); err != nil {
+			return 0, err
+		}
+	}
+
 	if rw.self.needsToRoll() {
 		if err := rw.roll(); err != nil {
 			return 0, err
@@ -568,21 +1335,35 @@ func (rw *rollingFileWriter) Write(bytes []byte) (n int, err error) {
 		if err != nil {
 			return 0, err
 		}
+		if !rw.recovered {
+			rw.recoverOldRolls()
+		}
 	}
 
 	n, err = rw.currentFile.Write(bytes)
 	rw.currentFileSize += int64(n)
-	return n, err
+	if err != nil {
+		return n, err
+	}
+	return n, rw.syncing.afterWrite(rw.currentFile, rw.clock.Now())
 }
 
+// Close closes the current file and waits for the queued archive jobs.
 func (rw *rollingFileWriter) Close() error {
 	if rw.currentFile != nil {
+		if err := rw.syncing.beforeClose(rw.currentFile); err != nil {
+			reportInternalError(err)
+		}
 		e := rw.currentFile.Close()
 		if e != nil {
 			return e
 		}
 		rw.currentFile = nil
 	}
+	rw.WaitArchives()
+	if rw.processLock != nil {
+		return rw.processLock.Close()
+	}
 	return nil
 }
 
@@ -594,6 +1375,95 @@ func (rw *rollingFileWriter) tempArchiveFile(archiveDir string) (*os.File, error
 	return ioutil.TempFile(tmp, "archived_logs")
 }
 
+// rollingArchiveJob is a set of rolls to be archived and removed in background.
+type rollingArchiveJob struct {
+	writer      *rollingFileWriter
+	rolls       []string  // Roll file names, oldest first
+	historySize int64     // Size of the rolls left in history. -1 - no retention limits
+	now         time.Time // Writer clock time when the job was queued
+}
+
+func (job *rollingArchiveJob) run() {
+	job.writer.archiveRolls(job.rolls)
+	job.writer.removeRolls(job.rolls)
+	if job.historySize >= 0 {
+		job.writer.deleteOldArchives(job.historySize, job.now)
+	}
+}
+
+// rollingArchiveQueue runs archive jobs one by one in a background goroutine. The goroutine
+// is started when a job is pushed to an empty queue and exits once the queue is empty.
+type rollingArchiveQueue struct {
+	jobs    []*rollingArchiveJob
+	maxSize int
+	running bool
+	queued  map[string]bool // Rolls of queued and running jobs
+	m       sync.Mutex
+	cond    *sync.Cond
+}
+
+func newRollingArchiveQueue(maxSize int) *rollingArchiveQueue {
+	queue := &rollingArchiveQueue{maxSize: maxSize, queued: make(map[string]bool)}
+	queue.cond = sync.NewCond(&queue.m)
+	return queue
+}
+
+// push adds the job to the queue, waiting while the queue is full.
+func (queue *rollingArchiveQueue) push(job *rollingArchiveJob) {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	for len(queue.jobs) >= queue.maxSize {
+		queue.cond.Wait()
+	}
+	queue.jobs = append(queue.jobs, job)
+	for _, roll := range job.rolls {
+		queue.queued[roll] = true
+	}
+	if !queue.running {
+		queue.running = true
+		go queue.process()
+	}
+}
+
+func (queue *rollingArchiveQueue) process() {
+	queue.m.Lock()
+	for len(queue.jobs) != 0 {
+		job := queue.jobs[0]
+		queue.jobs = queue.jobs[1:]
+		queue.cond.Broadcast()
+		queue.m.Unlock()
+
+		job.run()
+
+		queue.m.Lock()
+		for _, roll := range job.rolls {
+			delete(queue.queued, roll)
+		}
+	}
+	queue.running = false
+	queue.cond.Broadcast()
+	queue.m.Unlock()
+}
+
+// wait blocks until the queue is empty and the last job is done.
+func (queue *rollingArchiveQueue) wait() {
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	for queue.running {
+		queue.cond.Wait()
+	}
+}
+
+// isQueued returns true if the roll is waiting for archiving or being archived.
+func (queue *rollingArchiveQueue) isQueued(roll string) bool {
+	if queue == nil {
+		return false
+	}
+	queue.m.Lock()
+	defer queue.m.Unlock()
+	return queue.queued[roll]
+}
+
 // =============================================================================================
 //      Different types of rolling writers
 // =============================================================================================
@@ -664,12 +1534,13 @@ func (rws *rollingFileWriterSize) getCurrentFileName() string {
 }
 
 func (rws *rollingFileWriterSize) String() string {
-	return fmt.Sprintf("Rolling file writer (By SIZE): filename: %s, archive: %s, archivefile: %s, maxFileSize: %v, maxRolls: %v",
+	return fmt.Sprintf("Rolling file writer (By SIZE): filename: %s, archive: %s, archivefile: %s, maxFileSize: %v, maxRolls: %v%s",
 		rws.fileName,
 		rollingArchiveTypesStringRepresentation[rws.archiveType],
 		rws.archivePath,
 		rws.maxFileSize,
-		rws.maxRolls)
+		rws.maxRolls,
+		rws.optionsString())
 }
 
 // --------------------------------------------------
@@ -696,7 +1567,7 @@ func NewRollingFileWriterTime(fpath string, atype rollingArchiveType, apath stri
 }
 
 func (rwt *rollingFileWriterTime) needsToRoll() bool {
-	newName := time.Now().Format(rwt.timePattern)
+	newName := rwt.clock.Now().Format(rwt.timePattern)
 
 	if rwt.currentTimeFileName == "" {
 		// first run; capture the current name
@@ -707,6 +1578,10 @@ func (rwt *rollingFileWriterTime) needsToRoll() bool {
 	return newName != rwt.currentTimeFileName
 }
 
+func (rwt *rollingFileWriterTime) resetRollState() {
+	rwt.currentTimeFileName = rwt.clock.Now().Format(rwt.timePattern)
+}
+
 func (rwt *rollingFileWriterTime) isFileRollNameValid(rname string) bool {
 	if len(rname) == 0 {
 		return false
@@ -715,6 +1590,11 @@ func (rwt *rollingFileWriterTime) isFileRollNameValid(rname string) bool {
 	return err == nil
 }
 
+func (rwt *rollingFileWriterTime) rollTime(rname string) (time.Time, bool) {
+	t, err := time.ParseInLocation(rwt.timePattern, rname, time.Local)
+	return t, err == nil
+}
+
 type rollTimeFileTailsSlice struct {
 	data    []string
 	pattern string
@@ -742,22 +1622,151 @@ func (rwt *rollingFileWriterTime) sortFileRollNamesAsc(fs []string) ([]string, e
 
 func (rwt *rollingFileWriterTime) getNewHistoryRollFileName(_ []string) string {
 	newFileName := rwt.currentTimeFileName
-	rwt.currentTimeFileName = time.Now().Format(rwt.timePattern)
+	rwt.currentTimeFileName = rwt.clock.Now().Format(rwt.timePattern)
 	return newFileName
 }
 
 func (rwt *rollingFileWriterTime) getCurrentFileName() string {
 	if rwt.fullName {
-		return rwt.createFullFileName(rwt.fileName, time.Now().Format(rwt.timePattern))
+		return rwt.createFullFileName(rwt.fileName, rwt.clock.Now().Format(rwt.timePattern))
 	}
 	return rwt.fileName
 }
 
 func (rwt *rollingFileWriterTime) String() string {
-	return fmt.Sprintf("Rolling file writer (By TIME): filename: %s, archive: %s, archivefile: %s, pattern: %s, maxRolls: %v",
+	return fmt.Sprintf("Rolling file writer (By TIME): filename: %s, archive: %s, archivefile: %s, pattern: %s, maxRolls: %v%s",
 		rwt.fileName,
 		rollingArchiveTypesStringRepresentation[rwt.archiveType],
 		rwt.archivePath,
 		rwt.timePattern,
-		rwt.maxRolls)
+		rwt.maxRolls,
+		rwt.optionsString())
+}
+
+// --------------------------------------------------
+//      Rolling writer by DATE and SIZE
+// --------------------------------------------------
+
+// rollingFileWriterDateSize performs roll when a specified time interval has passed
+// or when file exceeds a specified limit within the interval. Roll names consist of
+// the date and the index of the roll within that date: 'file.log.2026-10-17.3'.
+type rollingFileWriterDateSize struct {
+	*rollingFileWriter
+	timePattern         string
+	maxFileSize         int64
+	currentTimeFileName string
+}
+
+func NewRollingFileWriterDateSize(fpath string, atype rollingArchiveType, apath string, maxSize int64, maxr int,
+	timePattern string, namemode rollingNameMode, archiveExploded bool) (*rollingFileWriterDateSize, error) {
+
+	rw, err := newRollingFileWriter(fpath, rollingTypeDateSize, atype, apath, maxr, namemode, archiveExploded, false)
+	if err != nil {
+		return nil, err
+	}
+	rws := &rollingFileWriterDateSize{rw, timePattern, maxSize, ""}
+	rws.self = rws
+	return rws, nil
+}
+
+func (rwds *rollingFileWriterDateSize) needsToRoll() bool {
+	newName := rwds.clock.Now().Format(rwds.timePattern)
+
+	if rwds.currentTimeFileName == "" {
+		// first run; capture the current name
+		rwds.currentTimeFileName = newName
+	}
+
+	return newName != rwds.currentTimeFileName || rwds.currentFileSize >= rwds.maxFileSize
+}
+
+func (rwds *rollingFileWriterDateSize) resetRollState() {
+	rwds.currentTimeFileName = rwds.clock.Now().Format(rwds.timePattern)
+}
+
+// parseRollName splits a roll name into its date and index parts.
+func (rwds *rollingFileWriterDateSize) parseRollName(rname string) (time.Time, int, bool) {
+	delim := strings.LastIndex(rname, rollingLogHistoryDelimiter)
+	if delim == -1 {
+		return time.Time{}, 0, false
+	}
+	date, err := time.ParseInLocation(rwds.timePattern, rname[:delim], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	index, err := strconv.Atoi(rname[delim+1:])
+	if err != nil || index < 0 {
+		return time.Time{}, 0, false
+	}
+	return date, index, true
+}
+
+func (rwds *rollingFileWriterDateSize) isFileRollNameValid(rname string) bool {
+	_, _, ok := rwds.parseRollName(rname)
+	return ok
+}
+
+func (rwds *rollingFileWriterDateSize) rollTime(rname string) (time.Time, bool) {
+	t, _, ok := rwds.parseRollName(rname)
+	return t, ok
+}
+
+type rollDateSizeFileTailsSlice struct {
+	data []string
+	rwds *rollingFileWriterDateSize
+}
+
+func (p rollDateSizeFileTailsSlice) Len() int {
+	return len(p.data)
+}
+
+func (p rollDateSizeFileTailsSlice) Less(i, j int) bool {
+	t1, i1, _ := p.rwds.parseRollName(p.data[i])
+	t2, i2, _ := p.rwds.parseRollName(p.data[j])
+	if t1.Equal(t2) {
+		return i1 < i2
+	}
+	return t1.Before(t2)
+}
+
+func (p rollDateSizeFileTailsSlice) Swap(i, j int) {
+	p.data[i], p.data[j] = p.data[j], p.data[i]
+}
+
+func (rwds *rollingFileWriterDateSize) sortFileRollNamesAsc(fs []string) ([]string, error) {
+	ss := rollDateSizeFileTailsSlice{data: fs, rwds: rwds}
+	sort.Sort(ss)
+	return ss.data, nil
+}
+
+func (rwds *rollingFileWriterDateSize) getNewHistoryRollFileName(otherLogFiles []string) string {
+	date := rwds.currentTimeFileName
+	rwds.currentTimeFileName = rwds.clock.Now().Format(rwds.timePattern)
+
+	v := 0
+	for _, file := range otherLogFiles {
+		rname := rwds.getFileRollName(file)
+		if !strings.HasPrefix(rname, date+rollingLogHistoryDelimiter) {
+			continue
+		}
+		if _, index, ok := rwds.parseRollName(rname); ok && index > v {
+			v = index
+		}
+	}
+	return fmt.Sprintf("%s%s%d", date, rollingLogHistoryDelimiter, v+1)
+}
+
+func (rwds *rollingFileWriterDateSize) getCurrentFileName() string {
+	return rwds.fileName
+}
+
+func (rwds *rollingFileWriterDateSize) String() string {
+	return fmt.Sprintf("Rolling file writer (By DATE and SIZE): filename: %s, archive: %s, archivefile: %s, pattern: %s, maxFileSize: %v, maxRolls: %v%s",
+		rwds.fileName,
+		rollingArchiveTypesStringRepresentation[rwds.archiveType],
+		rwds.archivePath,
+		rwds.timePattern,
+		rwds.maxFileSize,
+		rwds.maxRolls,
+		rwds.optionsString())
 }
@@ -25,9 +25,19 @@
 package seelog
 
 import (
+	"compress/flate"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/cihub/seelog/archive"
 )
 
 // fileWriterTestCase is declared in writers_filewriter_test.go
@@ -114,3 +124,531 @@ var rollingfileWriterTests = []*fileWriterTestCase{
 	createRollingSizeFileWriterTestCase([]string{"log.testlog", "log.testlog.1"}, "log.testlog", 10, 1, 2, []string{"log.testlog", "log.testlog.2", "dir/log.testlog.1.zip"}, rollingNameModePostfix, rollingArchiveZip, true, "dir"),
 	// ====================
 }
+
+func TestRollingFileWriterDateSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_datesize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRollingFileWriterDateSize(filepath.Join(dir, "app.log"), rollingArchiveNone, "", 10, 4,
+		"2006-01-02", rollingNameModePostfix, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	clock := NewManualClock(time.Date(2026, time.October, 17, 10, 0, 0, 0, time.Local))
+	writer.SetClock(clock)
+
+	write := func(count int) {
+		for i := 0; i < count; i++ {
+			if _, err := writer.Write([]byte("0123456789")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Rolls twice by size within the first day.
+	write(3)
+	// Rolls by date, then once more by size within the next day.
+	clock.Advance(24 * time.Hour)
+	write(2)
+
+	files, err := getDirFilePaths(dir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	expected := []string{"app.log", "app.log.2026-10-17.1", "app.log.2026-10-17.2", "app.log.2026-10-17.3", "app.log.2026-10-18.1"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+
+	// Exceeding maxrolls removes the oldest roll of the oldest date.
+	write(1)
+	history, err := writer.getSortedLogHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"app.log.2026-10-17.2", "app.log.2026-10-17.3", "app.log.2026-10-18.1", "app.log.2026-10-18.2"}
+	if !reflect.DeepEqual(history, expected) {
+		t.Fatalf("expected history %v, got %v", expected, history)
+	}
+}
+
+func TestRollingFileWriterMaxTotalSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_maxtotalsize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRollingFileWriterSize(filepath.Join(dir, "app.log"), rollingArchiveNone, "", 10, 0, rollingNameModePostfix, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.SetRetention(0, 25)
+
+	for i := 0; i < 6; i++ {
+		if _, err := writer.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := writer.getSortedLogHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"app.log.4", "app.log.5"}
+	if !reflect.DeepEqual(history, expected) {
+		t.Fatalf("expected history %v, got %v", expected, history)
+	}
+}
+
+func TestRollingFileWriterMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_maxage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archiveDir := filepath.Join(dir, "old")
+	writer, err := NewRollingFileWriterTime(filepath.Join(dir, "app.log"), rollingArchiveGzip, archiveDir, 0,
+		"2006-01-02", rollingNameModePostfix, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	// Archive age is counted by modification time, so the clock finishes close to the real time.
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day()-3, 10, 0, 0, 0, time.Local)
+	clock := NewManualClock(start)
+	writer.SetClock(clock)
+	writer.SetRetention(60*time.Hour, 0)
+
+	// An archive made a long time ago must be removed by age.
+	if err := os.MkdirAll(archiveDir, defaultDirectoryPermissions); err != nil {
+		t.Fatal(err)
+	}
+	staleArchive := filepath.Join(archiveDir, "app.log.2000-01-01.gz")
+	if err := ioutil.WriteFile(staleArchive, []byte("stale"), defaultFilePermissions); err != nil {
+		t.Fatal(err)
+	}
+	staleTime := now.Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(staleArchive, staleTime, staleTime); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := writer.Write([]byte("message\n")); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(24 * time.Hour)
+	}
+	// The last roll happens 72 hours after start: the first day roll is older than 60 hours.
+	day := func(n int) string {
+		return "app.log." + start.AddDate(0, 0, n).Format("2006-01-02")
+	}
+	history, err := writer.getSortedLogHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{day(1), day(2)}
+	if !reflect.DeepEqual(history, expected) {
+		t.Fatalf("expected history %v, got %v", expected, history)
+	}
+
+	archives, err := getDirFilePaths(archiveDir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{day(0) + ".gz"}
+	if !reflect.DeepEqual(archives, expected) {
+		t.Fatalf("expected archives %v, got %v", expected, archives)
+	}
+}
+
+func TestRollingFileWriterArchiveAsync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_archiveasync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Rolls left by a previous run must be archived after the start.
+	for _, name := range []string{"app.log.1", "app.log.2", "app.log.3"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("0123456789"), defaultFilePermissions); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archiveDir := filepath.Join(dir, "old")
+	writer, err := NewRollingFileWriterSize(filepath.Join(dir, "app.log"), rollingArchiveGzip, archiveDir, 10, 1, rollingNameModePostfix, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.SetArchiveAsync(1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := writer.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := getDirFilePaths(dir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	expected := []string{"app.log", "app.log.5"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+
+	archives, err := getDirFilePaths(archiveDir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(archives)
+	expected = []string{"app.log.1.gz", "app.log.2.gz", "app.log.3.gz", "app.log.4.gz"}
+	if !reflect.DeepEqual(archives, expected) {
+		t.Fatalf("expected archives %v, got %v", expected, archives)
+	}
+}
+
+var (
+	registerSlowArchiveType sync.Once
+	slowArchiveGate         chan struct{}
+)
+
+func TestRollingFileWriterArchiveQueueFull(t *testing.T) {
+	// Archiving of this type waits for the gate, so that every roll stays queued.
+	registerSlowArchiveType.Do(func() {
+		RegisterArchiveType("testslow", ArchiveFormat{
+			Extension: ".slow",
+			NewWriter: func(w io.Writer, level int) (archive.WriteCloser, error) {
+				<-slowArchiveGate
+				fw, err := flate.NewWriter(w, level)
+				if err != nil {
+					return nil, err
+				}
+				return archive.NewSingleFileWriter(fw), nil
+			},
+			NewReader: func(f *os.File) (archive.ReadCloser, error) {
+				return archive.NewSingleFileReader(flate.NewReader(f), f.Name()), nil
+			},
+		})
+	})
+	archiveType, _ := rollingArchiveTypeFromString("testslow")
+	slowArchiveGate = make(chan struct{})
+
+	dir, err := ioutil.TempDir("", "seelog_archivequeue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archiveDir := filepath.Join(dir, "old")
+	writer, err := NewRollingFileWriterSize(filepath.Join(dir, "app.log"), archiveType, archiveDir, 10, 1, rollingNameModePostfix, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every roll exceeds the total size, so each one is queued right after it is made.
+	writer.SetRetention(0, 5)
+	writer.SetArchiveAsync(1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := writer.Write([]byte(fmt.Sprintf("message %02d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The second roll must not reuse the name of the first one, which is still queued.
+	for i, name := range []string{"app.log.1", "app.log.2"} {
+		if !writer.archiveQueue.isQueued(name) {
+			t.Errorf("expected %s to be queued", name)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("message %02d", i); string(content) != expected {
+			t.Errorf("expected %s to contain %q, got %q", name, expected, content)
+		}
+	}
+	close(slowArchiveGate)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := getDirFilePaths(dir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"app.log"}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+}
+
+func TestRollingFileWriterRollHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_rollhooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archiveDir := filepath.Join(dir, "old")
+	writer, err := NewRollingFileWriterSize(filepath.Join(dir, "app.log"), rollingArchiveGzip, archiveDir, 10, 1, rollingNameModePostfix, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	writer.AddRollHook(func(event RollEvent) {
+		rel, _ := filepath.Rel(dir, event.Path)
+		events = append(events, fmt.Sprintf("%s %s %d", event.Type, rel, len(event.Sources)))
+	})
+	writer.AddRollHook(func(event RollEvent) {
+		panic("must be recovered")
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := writer.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Hooks are called in background, Close waits for them.
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"rolled app.log.1 0",
+		"rolled app.log.2 0",
+		"archived " + filepath.Join("old", "app.log.1.gz") + " 1",
+		"deleted app.log.1 0",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected events %v, got %v", expected, events)
+	}
+}
+
+func TestRollingFileWriterRollHooksOwnFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_rollhooksown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRollingFileWriterSize(filepath.Join(dir, "app.log"), rollingArchiveNone, "", 10, 0, rollingNameModePostfix, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hook takes every roll away, but only after all writes are done.
+	gate := make(chan struct{})
+	writer.AddRollHook(func(event RollEvent) {
+		<-gate
+		if event.Type == RollEventRolled {
+			os.Remove(event.Path)
+		}
+	})
+
+	for i := 0; i < 4; i++ {
+		if _, err := writer.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(gate)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := getDirFilePaths(dir, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"app.log"}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+}
+
+func TestRollingFileWriterLocking(t *testing.T) {
+	if !fileLockingSupported {
+		t.Skip(errFileLockingNotSupported)
+	}
+	dir, err := ioutil.TempDir("", "seelog_locking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Two writers of one file behave like writers in different processes,
+	// as flock locks are bound to open files.
+	path := filepath.Join(dir, "app.log")
+	clock := NewManualClock(time.Date(2026, time.October, 17, 10, 0, 0, 0, time.Local))
+	var writers []*rollingFileWriterTime
+	for i := 0; i < 2; i++ {
+		writer, err := NewRollingFileWriterTime(path, rollingArchiveNone, "", 0, "2006-01-02", rollingNameModePostfix, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer writer.Close()
+		writer.SetClock(clock)
+		if err := writer.SetLocking(true); err != nil {
+			t.Fatal(err)
+		}
+		writers = append(writers, writer)
+	}
+
+	for _, day := range []string{"17", "18"} {
+		for i, writer := range writers {
+			if _, err := writer.Write([]byte(fmt.Sprintf("%s:%d;", day, i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		clock.Advance(24 * time.Hour)
+	}
+
+	expected := map[string]string{
+		"app.log":            "18:0;18:1;",
+		"app.log.2026-10-17": "17:0;17:1;",
+	}
+	for name, content := range expected {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("expected %q in %s, got %q", content, name, string(data))
+		}
+	}
+}
+
+var registerTestArchiveType sync.Once
+
+func TestRegisterArchiveType(t *testing.T) {
+	registerTestArchiveType.Do(func() {
+		RegisterArchiveType("testflate", ArchiveFormat{
+			Extension: ".fl",
+			NewWriter: func(w io.Writer, level int) (archive.WriteCloser, error) {
+				fw, err := flate.NewWriter(w, level)
+				if err != nil {
+					return nil, err
+				}
+				return archive.NewSingleFileWriter(fw), nil
+			},
+			NewReader: func(f *os.File) (archive.ReadCloser, error) {
+				return archive.NewSingleFileReader(flate.NewReader(f), f.Name()), nil
+			},
+		})
+	})
+
+	archiveType, ok := rollingArchiveTypeFromString("testflate")
+	if !ok {
+		t.Fatal("registered archive type is not found")
+	}
+
+	dir, err := ioutil.TempDir("", "seelog_archivetype")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archivePath := filepath.Join(dir, "log.tar.fl")
+	writer, err := NewRollingFileWriterSize(filepath.Join(dir, "app.log"), archiveType, archivePath, 10, 1, rollingNameModePostfix, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.SetArchiveLevel(flate.BestCompression); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.SetArchiveLevel(42); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+	defer writer.Close()
+
+	// Each archiving appends a roll to the same tar archive.
+	for i := 0; i < 4; i++ {
+		if _, err := writer.Write([]byte(fmt.Sprintf("message %d\n", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := compressionTypes[archiveType].unarchiver(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		name, err := r.NextFile()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, filepath.Base(name))
+	}
+	expected := []string{"app.log.1", "app.log.2"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected archived files %v, got %v", expected, names)
+	}
+}
+
+func TestRollingFileWriterSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seelog_symlink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRollingFileWriterTime(filepath.Join(dir, "app.log"), rollingArchiveNone, "", 0,
+		"2006-01-02", rollingNameModePostfix, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	clock := NewManualClock(time.Date(2026, time.October, 17, 10, 0, 0, 0, time.Local))
+	writer.SetClock(clock)
+	if err := writer.SetSymlink("app.log"); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(dir, "app.log")
+	for _, day := range []string{"2026-10-17", "2026-10-18"} {
+		if _, err := writer.Write([]byte(day)); err != nil {
+			t.Fatal(err)
+		}
+		target, err := os.Readlink(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != "app.log."+day {
+			t.Errorf("expected link to app.log.%s, got %s", day, target)
+		}
+		data, err := ioutil.ReadFile(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != day {
+			t.Errorf("expected %q through the link, got %q", day, string(data))
+		}
+		clock.Advance(24 * time.Hour)
+	}
+
+	sizeWriter, err := NewRollingFileWriterSize(filepath.Join(dir, "size.log"), rollingArchiveNone, "", 10, 0, rollingNameModePostfix, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sizeWriter.SetSymlink("size.log"); err == nil {
+		t.Error("expected an error for a symlink replacing the log file")
+	}
+}
@@ -49,6 +49,7 @@ type smtpWriter struct {
 	hostName           string
 	hostPort           string
 	hostNameWithPort   string
+	userName           string
 	senderAddress      string
 	senderName         string
 	recipientAddresses []string
@@ -64,6 +65,7 @@ func NewSMTPWriter(sa, sn string, ras []string, hn, hp, un, pwd string, cacdps [
 		hostName:           hn,
 		hostPort:           hp,
 		hostNameWithPort:   fmt.Sprintf("%s:%s", hn, hp),
+		userName:           un,
 		senderAddress:      sa,
 		senderName:         sn,
 		recipientAddresses: ras,